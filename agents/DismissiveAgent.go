@@ -12,22 +12,23 @@ type DismissiveAgent struct {
 }
 
 func CreateDismissiveAgent(server infra.IServer) *DismissiveAgent {
-	worldview := infra.NewWorldview(byte(0b01))
+	worldview := infra.NewWorldview(byte(0b01), server.GetRNG())
 
 	extendedAgent := CreateExtendedAgent(server, worldview)
 
 	// Set Dismissive-style attachment: low anxiety, high avoidance
 	extendedAgent.attachment = infra.Attachment{
-		Anxiety:   randInRange(0.0, 0.5),
-		Avoidance: randInRange(0.5, 1.0),
+		Anxiety:   extendedAgent.randInRange(0.0, 0.5),
+		Avoidance: extendedAgent.randInRange(0.5, 1.0),
 		Type:      infra.DISMISSIVE,
 	}
+	extendedAgent.speed = server.GetSpeed(infra.DISMISSIVE)
 	// these ranges to be tweaked
 	extendedAgent.PTW = infra.PTSParams{
-		CheckProb: randInRange(0.0, 0.5),
-		ReplyProb: randInRange(0.0, 0.5),
-		Alpha:     randInRange(0.0, 0.5),
-		Beta:      randInRange(0.0, 0.5),
+		CheckProb: extendedAgent.randInRange(0.0, 0.5),
+		ReplyProb: extendedAgent.randInRange(0.0, 0.5),
+		Alpha:     extendedAgent.randInRange(0.0, 0.5),
+		Beta:      extendedAgent.randInRange(0.0, 0.5),
 	}
 
 	return &DismissiveAgent{
@@ -46,7 +47,7 @@ func (da *DismissiveAgent) GetTargetPosition() (infra.PositionVector, bool) {
 	var closestInNetwork infra.IExtendedAgent = nil
 	minDist := math.Inf(1)
 
-	for otherID := range da.network {
+	for _, otherID := range infra.SortedIDs(da.network) {
 		// Ignore self
 		if otherID == da.GetID() {
 			continue
@@ -59,7 +60,7 @@ func (da *DismissiveAgent) GetTargetPosition() (infra.PositionVector, bool) {
 			continue
 		}
 
-		dist := da.position.Dist(otherAgent.GetPosition())
+		dist := da.GetGrid().Dist(da.position, otherAgent.GetPosition())
 		if dist < minDist {
 			minDist = dist
 			closestInNetwork = otherAgent
@@ -74,5 +75,5 @@ func (da *DismissiveAgent) GetTargetPosition() (infra.PositionVector, bool) {
 	selfPos := da.GetPosition()
 
 	// closest->self + self == self - closest + self
-	return selfPos.Sub(closestPos).Add(selfPos), true
+	return selfPos.Add(da.GetGrid().Displacement(closestPos, selfPos)), true
 }
@@ -2,10 +2,12 @@ package agents
 
 import (
 	"math"
-	"math/rand"
+	"math/rand/v2"
+	"slices"
 	"sort"
 
 	"github.com/MattSScott/basePlatformSOMAS/v2/pkg/agent"
+	"github.com/MattSScott/basePlatformSOMAS/v2/pkg/message"
 	"github.com/aaashah/TMT_FYP/gameRecorder"
 	"github.com/aaashah/TMT_FYP/infra"
 	"github.com/google/uuid"
@@ -15,14 +17,19 @@ type ExtendedAgent struct {
 	*agent.BaseAgent[infra.IExtendedAgent]
 	infra.IServer
 
+	id uuid.UUID // drawn from the server's RNG, shadows the BaseAgent ID
+
 	telomere *infra.Telomere
 
 	position infra.PositionVector
+	speed    int     // steps taken per turn
+	energy   float64 // only used when the grid has resources
 
 	//History Tracking
 	clusterID          int
 	heroism            int // number of times agent volunteered self-sacrifices
 	eliminationHistory *infra.EliminationHistory
+	lineage            infra.Lineage
 
 	// Social network and kinship group
 	network       map[uuid.UUID]float32 // stores relationship strengths
@@ -42,21 +49,72 @@ type ExtendedAgent struct {
 
 func CreateExtendedAgent(server infra.IServer, worldview *infra.Worldview) *ExtendedAgent {
 	initAgents := float64(server.GetInitNumberAgents())
-	gridWidth, gridHeight := server.GetGridDims()
+	rng := server.GetRNG()
 
 	return &ExtendedAgent{
 		BaseAgent:          agent.CreateBaseAgent(server),
-		IServer:            server,                                                               // Type assert the server functions to IServer interface
-		attachment:         infra.Attachment{Anxiety: rand.Float32(), Avoidance: rand.Float32()}, // Randomised anxiety and avoidance
-		heroism:            0,                                                                    //start at 0 increment if chose to self-sacrifice
+		IServer:            server, // Type assert the server functions to IServer interface
+		id:                 infra.NewAgentID(rng),
+		attachment:         infra.Attachment{Anxiety: rng.Float32(), Avoidance: rng.Float32()}, // Randomised anxiety and avoidance
+		heroism:            0,                                                                  //start at 0 increment if chose to self-sacrifice
 		network:            make(map[uuid.UUID]float32),
 		telomere:           infra.NewTelomere(),
 		worldview:          worldview,
 		ysterofimia:        infra.NewYsterofimia(),
 		ptsStats:           infra.NewPTS_Stats(),
 		eliminationHistory: infra.NewEliminationHistory(initAgents),
+		lineage:            infra.FounderLineage(),
 		agentIsAlive:       true,
-		position:           infra.PositionVector{X: rand.Intn(gridWidth), Y: rand.Intn(gridHeight)},
+		energy:             server.GetInitialEnergy(),
+	}
+}
+
+// restoreExtendedAgent rebuilds an agent from a checkpoint without drawing from the server's RNG
+func restoreExtendedAgent(server infra.IServer, state infra.AgentState) *ExtendedAgent {
+	network := make(map[uuid.UUID]float32, len(state.Network))
+	for otherID, strength := range state.Network {
+		network[otherID] = strength
+	}
+	ysterofimia := state.Ysterofimia
+	if state.Speed == 0 {
+		// checkpoints from before agents had a speed
+		state.Speed = server.GetSpeed(state.Attachment.Type)
+	}
+	return &ExtendedAgent{
+		BaseAgent:          agent.CreateBaseAgent(server),
+		IServer:            server,
+		id:                 state.ID,
+		telomere:           infra.RestoreTelomere(state.Telomere),
+		position:           state.Position,
+		speed:              state.Speed,
+		energy:             state.Energy,
+		clusterID:          state.ClusterID,
+		heroism:            state.Heroism,
+		eliminationHistory: infra.RestoreEliminationHistory(state.EliminationHistory),
+		lineage:            state.Lineage,
+		network:            network,
+		networkLength:      state.NetworkLength,
+		attachment:         state.Attachment,
+		PTW:                state.PTW,
+		ptsStats:           infra.RestorePTSStats(state.PTSStats),
+		worldview:          infra.RestoreWorldview(state.Worldview),
+		ysterofimia:        &ysterofimia,
+		agentIsAlive:       state.IsAlive,
+	}
+}
+
+// RestoreAgent rebuilds an agent of the right attachment type from its checkpointed state
+func RestoreAgent(server infra.IServer, state infra.AgentState) infra.IExtendedAgent {
+	extendedAgent := restoreExtendedAgent(server, state)
+	switch state.Attachment.Type {
+	case infra.SECURE:
+		return &SecureAgent{ExtendedAgent: extendedAgent}
+	case infra.DISMISSIVE:
+		return &DismissiveAgent{ExtendedAgent: extendedAgent}
+	case infra.PREOCCUPIED:
+		return &PreoccupiedAgent{ExtendedAgent: extendedAgent}
+	default:
+		return &FearfulAgent{ExtendedAgent: extendedAgent}
 	}
 }
 
@@ -64,6 +122,16 @@ func CreateExtendedAgent(server infra.IServer, worldview *infra.Worldview) *Exte
 
 func (ea *ExtendedAgent) AgentInitialised() {}
 
+// GetID returns the seeded agent ID. BaseAgent draws its own ID from the
+// global uuid source, which would make runs irreproducible.
+func (ea *ExtendedAgent) GetID() uuid.UUID {
+	return ea.id
+}
+
+func (ea *ExtendedAgent) CreateBaseMessage() message.BaseMessage {
+	return message.BaseMessage{Sender: ea.GetID()}
+}
+
 func (ea *ExtendedAgent) GetName() uuid.UUID {
 	return ea.GetID()
 }
@@ -84,6 +152,18 @@ func (ea *ExtendedAgent) GetPosition() infra.PositionVector {
 	return ea.position
 }
 
+func (ea *ExtendedAgent) GetSpeed() int {
+	return ea.speed
+}
+
+func (ea *ExtendedAgent) GetEnergy() float64 {
+	return ea.energy
+}
+
+func (ea *ExtendedAgent) AddEnergy(delta float64) {
+	ea.energy += delta
+}
+
 func (ea *ExtendedAgent) SetPosition(newPos infra.PositionVector) {
 	ea.position = newPos
 }
@@ -92,8 +172,8 @@ func (ea *ExtendedAgent) GetAttachment() infra.Attachment {
 	return ea.attachment
 }
 
-func randInRange(min, max float32) float32 {
-	return min + rand.Float32()*(max-min)
+func (ea *ExtendedAgent) randInRange(min, max float32) float32 {
+	return min + ea.GetRNG().Float32()*(max-min)
 }
 
 // Finds closest friend in social network
@@ -101,7 +181,7 @@ func (ea *ExtendedAgent) FindClosestFriend() infra.IExtendedAgent {
 	var closestFriends []infra.IExtendedAgent
 	minDist := math.Inf(1)
 
-	for friendID := range ea.network {
+	for _, friendID := range infra.SortedIDs(ea.network) {
 		if friendID == ea.GetID() {
 			continue // Skip self
 		}
@@ -111,7 +191,7 @@ func (ea *ExtendedAgent) FindClosestFriend() infra.IExtendedAgent {
 			continue
 		}
 
-		dist := ea.position.Dist(agentInterface.GetPosition())
+		dist := ea.GetGrid().Dist(ea.position, agentInterface.GetPosition())
 		if dist < minDist {
 			minDist = dist
 			closestFriends = []infra.IExtendedAgent{agentInterface} // start new list
@@ -123,15 +203,21 @@ func (ea *ExtendedAgent) FindClosestFriend() infra.IExtendedAgent {
 		return nil
 	}
 
-	return closestFriends[rand.Intn(len(closestFriends))] // pick randomly
+	return closestFriends[ea.GetRNG().IntN(len(closestFriends))] // pick randomly
 }
 
 func (ea *ExtendedAgent) GetClusterID() int {
 	return ea.clusterID
 }
 
+// SetClusterID moves the agent to another cluster and tells the grid, so that its
+// index of cluster members stays current
 func (ea *ExtendedAgent) SetClusterID(id int) {
+	if id == ea.clusterID {
+		return
+	}
 	ea.clusterID = id
+	ea.GetGrid().ClustersChanged()
 }
 
 // func (ea *ExtendedAgent) AppendClusterHistory(clusterID int, clusterSize int) {
@@ -172,10 +258,13 @@ func (ea *ExtendedAgent) UpdateWorldview(trend float64, seasonal int) {
 	ea.worldview.UpdateWorldview(trend, seasonal)
 }
 
-// func (ea *ExtendedAgent) SetParents(parent1, parent2 uuid.UUID) {
-// 	ea.Parent1 = parent1
-// 	ea.Parent2 = parent2
-// }
+func (ea *ExtendedAgent) GetLineage() infra.Lineage {
+	return ea.lineage
+}
+
+func (ea *ExtendedAgent) SetLineage(lineage infra.Lineage) {
+	ea.lineage = lineage
+}
 
 func (ea *ExtendedAgent) GetYsterofimia() *infra.Ysterofimia {
 	return ea.ysterofimia
@@ -189,6 +278,33 @@ func (ea *ExtendedAgent) IsAlive() bool {
 	return ea.agentIsAlive
 }
 
+// GetState snapshots the agent for a checkpoint
+func (ea *ExtendedAgent) GetState() infra.AgentState {
+	network := make(map[uuid.UUID]float32, len(ea.network))
+	for otherID, strength := range ea.network {
+		network[otherID] = strength
+	}
+	return infra.AgentState{
+		ID:                 ea.id,
+		Attachment:         ea.attachment,
+		PTW:                ea.PTW,
+		Telomere:           ea.telomere.State(),
+		Position:           ea.position,
+		Speed:              ea.speed,
+		Energy:             ea.energy,
+		ClusterID:          ea.clusterID,
+		Heroism:            ea.heroism,
+		EliminationHistory: ea.eliminationHistory.State(),
+		Lineage:            ea.lineage,
+		Network:            network,
+		NetworkLength:      ea.networkLength,
+		PTSStats:           ea.ptsStats.State(),
+		Worldview:          ea.worldview.State(),
+		Ysterofimia:        *ea.ysterofimia,
+		IsAlive:            ea.agentIsAlive,
+	}
+}
+
 // take the total number of eliminations you've ever seen (1)
 // divide it by an agent-specific tolerance (2)
 func (ea *ExtendedAgent) ClusterEliminations() float32 {
@@ -217,28 +333,61 @@ func (ea *ExtendedAgent) RelativeAgeToNetwork() float32 {
 	return float32(agePos) / float32(networkSize)
 }
 
+// closestClusterMate is the nearest other agent in the same cluster, and reports
+// false if the agent is alone in it
+func (ea *ExtendedAgent) closestClusterMate() (infra.IExtendedAgent, bool) {
+	return ea.GetGrid().NearestAgent(ea.position, func(other infra.IExtendedAgent) bool {
+		return other.GetClusterID() == ea.clusterID && other.GetID() != ea.GetID()
+	})
+}
+
+// clusterMates lists, by ID, the other living agents in the agent's cluster
+func (ea *ExtendedAgent) clusterMates(grid *infra.Grid) []infra.IExtendedAgent {
+	return slices.DeleteFunc(grid.ClusterMembers(ea.clusterID), func(other infra.IExtendedAgent) bool {
+		return !ea.isClusterMate(other)
+	})
+}
+
+// isClusterMate reports whether other is another agent in the same cluster that
+// is still alive. Agents that die at the end of an iteration stay on the grid
+// until their memorials are raised.
+func (ea *ExtendedAgent) isClusterMate(other infra.IExtendedAgent) bool {
+	if other.GetClusterID() != ea.clusterID || other.GetID() == ea.GetID() {
+		return false
+	}
+	_, alive := ea.GetAgentByID(other.GetID())
+	return alive
+}
+
+// GetMemorialProximity weighs the pull of cluster mates against that of memorials,
+// each by inverse distance. Only those within the proximity radius count, unless
+// it is 0, when everything on the grid does.
 func (ea *ExtendedAgent) GetMemorialProximity(grid *infra.Grid) float32 {
 	selfPosition := ea.GetPosition()
-	clusterID := ea.GetClusterID()
-	memorials := append(grid.Tombstones, grid.Temples...)
+	var memorials []infra.Memorial
+	var clusterMates []infra.IExtendedAgent
+	if radius := ea.GetProximityRadius(); radius > 0 {
+		memorials = grid.MemorialsWithin(selfPosition, radius)
+		clusterMates = grid.AgentsWithin(selfPosition, radius, ea.isClusterMate)
+	} else {
+		memorials = grid.Memorials()
+		clusterMates = ea.clusterMates(grid)
+	}
 
 	epsilon := 1e-3
 
 	totalMemorialInfluence := 0.0
 	for _, mem := range memorials {
-		distToMem := selfPosition.Dist(mem)
+		distToMem := grid.Dist(selfPosition, mem.PositionVector)
 		if distToMem > epsilon {
-			totalMemorialInfluence += 1 / distToMem
+			totalMemorialInfluence += mem.Salience / distToMem
 		}
 	}
 
 	totalClusterInfluence := 0.0
-	for _, ag := range ea.GetAgentMap() {
-		if ag.GetClusterID() != clusterID || ag.GetID() == ea.GetID() {
-			continue
-		}
+	for _, ag := range clusterMates {
 		otherPosition := ag.GetPosition()
-		distToAgent := selfPosition.Dist(otherPosition)
+		distToAgent := grid.Dist(selfPosition, otherPosition)
 		if distToAgent > epsilon {
 			totalClusterInfluence += 1 / distToAgent
 		}
@@ -264,17 +413,10 @@ func (ea *ExtendedAgent) GetMemorialProximity(grid *infra.Grid) float32 {
 
 func (ea *ExtendedAgent) GetCPR() float32 {
 	// compute cluster profiles
-	agentMap := ea.GetAgentMap()
-	clusterID := ea.GetClusterID()
 	clusterAlignments := []float64{}
-	for _, otherAgent := range agentMap {
-		if otherAgent.GetID() == ea.GetID() {
-			continue // skip self
-		}
-		if otherAgent.GetClusterID() == clusterID {
-			score := ea.worldview.CompareWorldviews(otherAgent.GetWorldview())
-			clusterAlignments = append(clusterAlignments, score)
-		}
+	for _, otherAgent := range ea.clusterMates(ea.GetGrid()) {
+		score := ea.worldview.CompareWorldviews(otherAgent.GetWorldview())
+		clusterAlignments = append(clusterAlignments, score)
 	}
 
 	if len(clusterAlignments) == 0 {
@@ -292,7 +434,7 @@ func (ea *ExtendedAgent) GetNPR() float32 {
 	// compute network profiles
 	networkAlignments := []float64{}
 	agentMap := ea.GetAgentMap()
-	for friendID := range ea.network {
+	for _, friendID := range infra.SortedIDs(ea.network) {
 		if other, ok := agentMap[friendID]; ok {
 			score := ea.worldview.CompareWorldviews(other.GetWorldview())
 			networkAlignments = append(networkAlignments, score)
@@ -339,8 +481,8 @@ func (ea *ExtendedAgent) GetProSocialEsteem() float32 {
 		return 0.0 // No neighbors, no esteem
 	}
 	sumEsteem := float32(0.0)
-	for _, esteem := range network {
-		sumEsteem += esteem
+	for _, friendID := range infra.SortedIDs(network) {
+		sumEsteem += network[friendID]
 	}
 
 	return sumEsteem / float32(len(network))
@@ -366,71 +508,77 @@ func (ea *ExtendedAgent) GetHeroismTendency() float32 {
 	return float32(index) / float32(len(heroismScores))
 }
 
-func (ea *ExtendedAgent) ComputeMortalitySalience(grid *infra.Grid) float32 {
-	ce := ea.ClusterEliminations()
-	ne := ea.NetworkEliminations()
-	ra := ea.RelativeAgeToNetwork()
-	mp := ea.GetMemorialProximity(grid)
-	// fmt.Printf("Agent %v MS Scores: CE=%.2f, NE=%.2f, RA=%.2f, MP=%.2f\n", ea.GetID(), ce, ne, ra, mp)
-
-	return infra.W1*ce + infra.W2*ne + infra.W3*ra + infra.W4*mp
+func (ea *ExtendedAgent) ComputeMortalitySalience(grid *infra.Grid) infra.MortalitySalienceScores {
+	return infra.MortalitySalienceScores{
+		ClusterEliminations: ea.ClusterEliminations(),
+		NetworkEliminations: ea.NetworkEliminations(),
+		RelativeAge:         ea.RelativeAgeToNetwork(),
+		MemorialProximity:   ea.GetMemorialProximity(grid),
+	}
 }
 
-func (ea *ExtendedAgent) ComputeWorldviewValidation() float32 {
-	cpr := ea.GetCPR()
-	npr := ea.GetNPR()                                      // compute NPR
-	ysterofimia := ea.GetYsterofimia().ComputeYsterofimia() // compute ysterofimia
-	// fmt.Printf("Agent %v WV Scores: CPR=%.2f, NPR=%.2f, Ysterofimia=%.2f\n", ea.GetID(), cpr, npr, ysterofimia)
-
-	return infra.W5*cpr + infra.W6*npr + infra.W7*ysterofimia
+func (ea *ExtendedAgent) ComputeWorldviewValidation() infra.WorldviewValidationScores {
+	return infra.WorldviewValidationScores{
+		ClusterProfile: ea.GetCPR(),
+		NetworkProfile: ea.GetNPR(),
+		Ysterofimia:    ea.GetYsterofimia().ComputeYsterofimia(),
+	}
 }
 
-func (ea *ExtendedAgent) ComputeRelationshipValidation() float32 {
-	est := ea.GetEstrangement()                // compute EST
-	pse := ea.GetProSocialEsteem()             // compute PSE
-	heroismTendency := ea.GetHeroismTendency() // compute heroism tendency
-	// fmt.Printf("Agent %v RV Scores: EST=%.2f, PSE=%.2f, HeroismTendency=%.2f\n", ea.GetID(), est, pse, heroismTendency)
-
-	return infra.W8*est + infra.W9*pse + infra.W10*heroismTendency
+func (ea *ExtendedAgent) ComputeRelationshipValidation() infra.RelationshipValidationScores {
+	return infra.RelationshipValidationScores{
+		Estrangement:    ea.GetEstrangement(),
+		ProSocialEsteem: ea.GetProSocialEsteem(),
+		HeroismTendency: ea.GetHeroismTendency(),
+	}
 }
 
 // Decision-making logic
 func (ea *ExtendedAgent) GetASMDecision(grid *infra.Grid) infra.ASMDecison {
+	outcome := ea.EvaluateASMDecision(grid, ea.GetRNG())
+	ea.ApplyASMOutcome(outcome)
+	return outcome.Decision
+}
+
+// EvaluateASMDecision scores the agent's modules and decides, drawing only from rng
+// and changing nothing, so that agents can decide concurrently
+func (ea *ExtendedAgent) EvaluateASMDecision(grid *infra.Grid, rng *rand.Rand) infra.ASMOutcome {
 	threshold := ea.GetASMThreshold()
 
-	ms := ea.ComputeMortalitySalience(grid)
-	wv := ea.ComputeWorldviewValidation()
-	rv := ea.ComputeRelationshipValidation()
+	scores := infra.ASMScores{
+		MortalitySalience:      ea.ComputeMortalitySalience(grid),
+		WorldviewValidation:    ea.ComputeWorldviewValidation(),
+		RelationshipValidation: ea.ComputeRelationshipValidation(),
+	}
+	weights := ea.GetASMWeights(ea.attachment.Type)
+	ms := scores.MortalitySalience.Score(weights.MortalitySalience)
+	wv := scores.WorldviewValidation.Score(weights.WorldviewValidation)
+	rv := scores.RelationshipValidation.Score(weights.RelationshipValidation)
 
 	// Debug log
 	// fmt.Printf("Agent %v ASM Scores: MS=%.2f, WV=%.2f, RV=%.2f\n\n", ea.GetID(), ms, wv, rv)
 	// fmt.Printf("AGE: %d\n\n", ea.GetAge())
 	thresholdScore := 0.0
-
-	sum := 0
 	for _, score := range []float32{ms, wv, rv} {
 		if threshold > 0 {
 			thresholdScore += min(float64(score/threshold), 1)
 		} else {
 			thresholdScore += 1
 		}
-		if score > threshold {
-			sum += 1
-		} else {
-			sum -= 1
-		}
 	}
 
-	ea.SubmitDecisionThreshold(ea.GetID(), thresholdScore/3)
+	moduleScores := infra.ModuleScores{MortalitySalience: ms, WorldviewValidation: wv, RelationshipValidation: rv}
+	decision := ea.GetDecisionRule().Decide(moduleScores, threshold, rng)
+	return infra.ASMOutcome{Scores: scores, ThresholdScore: thresholdScore / 3, Decision: decision}
+}
 
-	if sum > 0 {
+// ApplyASMOutcome submits a decision to the server and counts a volunteer's heroism
+func (ea *ExtendedAgent) ApplyASMOutcome(outcome infra.ASMOutcome) {
+	ea.SubmitDecisionThreshold(ea.GetID(), outcome.ThresholdScore)
+	if outcome.Decision == infra.SELF_SACRIFICE {
 		ea.IncrementHeroism()
-		return infra.SELF_SACRIFICE // Self-sacrifice
-	} else if sum < 0 {
-		return infra.NOT_SELF_SACRIFICE // Reject self-sacrifice
-	} else {
-		return infra.INACTION // No action
 	}
+	ea.SubmitASMDecision(ea.GetID(), outcome.Scores, outcome.Decision)
 }
 
 // -------PTS-------
@@ -479,7 +627,7 @@ func (ea *ExtendedAgent) CreateReplyMessage() *infra.ReplyMessage {
 
 func (ea *ExtendedAgent) HandleWellbeingCheckMessage(msg *infra.WellbeingCheckMessage) {
 	//fmt.Printf("Agent %v received wellbeing check from %v\n", ea.GetID(), msg.Sender)
-	if rand.Float32() < ea.PTW.ReplyProb {
+	if ea.GetRNG().Float32() < ea.PTW.ReplyProb {
 		reply := ea.CreateReplyMessage()
 		ea.SendSynchronousMessage(reply, msg.Sender)
 		// fmt.Printf("Agent %v sending reply message to %v\n", ea.GetID(), msg.Sender)
@@ -512,27 +660,28 @@ func (ea *ExtendedAgent) ReceiveSeveredConnected(uuid.UUID) {
 // ----------------------- Data Recording Functions -----------------------
 
 func (ea *ExtendedAgent) RecordAgentJSON(instance infra.IExtendedAgent) gameRecorder.JSONAgentRecord {
-	styleMap := map[infra.AttachmentType]string{
-		infra.DISMISSIVE:  "Dismissive",
-		infra.FEARFUL:     "Fearful",
-		infra.PREOCCUPIED: "Preoccupied",
-		infra.SECURE:      "Secure",
-	}
-
 	return gameRecorder.JSONAgentRecord{
 		ID:                  ea.GetID().String(),
 		IsAlive:             ea.IsAlive(),
 		Age:                 ea.GetAge(),
-		AttachmentStyle:     styleMap[ea.attachment.Type],
+		AttachmentStyle:     ea.attachment.Type.String(),
 		AttachmentAnxiety:   ea.attachment.Anxiety,
 		AttachmentAvoidance: ea.attachment.Avoidance,
 		ClusterID:           ea.clusterID,
 		Position:            gameRecorder.Position{X: ea.position.X, Y: ea.position.Y},
 		// Worldview:           ea.worldview,
-		Heroism: ea.heroism,
-		//MortalitySalience:      ea.MortalitySalience,
-		//WorldviewValidation:    ea.WorldviewValidation,
-		//RelationshipValidation: ea.RelationshipValidation,
-		//ASMDecison: 		    ea.GetASMDecision(nil),
+		Heroism:        ea.heroism,
+		Parent1:        uuidToString(ea.lineage.Parent1),
+		Parent2:        uuidToString(ea.lineage.Parent2),
+		Generation:     ea.lineage.Generation,
+		BirthIteration: ea.lineage.BirthIteration,
+	}
+}
+
+// uuidToString leaves nil IDs (a founder's parents) empty in the logs
+func uuidToString(id uuid.UUID) string {
+	if id == uuid.Nil {
+		return ""
 	}
+	return id.String()
 }
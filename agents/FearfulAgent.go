@@ -2,7 +2,6 @@ package agents
 
 import (
 	"fmt"
-	"math"
 
 	"github.com/aaashah/TMT_FYP/infra"
 )
@@ -12,21 +11,22 @@ type FearfulAgent struct {
 }
 
 func CreateFearfulAgent(server infra.IServer) *FearfulAgent {
-	worldview := infra.NewWorldview(byte(0b00))
+	worldview := infra.NewWorldview(byte(0b00), server.GetRNG())
 	extendedAgent := CreateExtendedAgent(server, worldview)
 
 	// Set Fearful-style attachment: high anxiety, high avoidance
 	extendedAgent.attachment = infra.Attachment{
-		Anxiety:   randInRange(0.5, 1.0),
-		Avoidance: randInRange(0.5, 1.0),
+		Anxiety:   extendedAgent.randInRange(0.5, 1.0),
+		Avoidance: extendedAgent.randInRange(0.5, 1.0),
 		Type:      infra.FEARFUL,
 	}
+	extendedAgent.speed = server.GetSpeed(infra.FEARFUL)
 	// these ranges to be tweaked
 	extendedAgent.PTW = infra.PTSParams{
-		CheckProb: randInRange(0.5, 1.0),
-		ReplyProb: randInRange(0.0, 0.5),
-		Alpha:     randInRange(0.0, 0.5),
-		Beta:      randInRange(0.5, 1.0),
+		CheckProb: extendedAgent.randInRange(0.5, 1.0),
+		ReplyProb: extendedAgent.randInRange(0.0, 0.5),
+		Alpha:     extendedAgent.randInRange(0.0, 0.5),
+		Beta:      extendedAgent.randInRange(0.5, 1.0),
 	}
 
 	return &FearfulAgent{
@@ -41,30 +41,8 @@ func (fa *FearfulAgent) AgentInitialised() {
 // Fearful agent movement policy
 // Moves away from closest in cluster
 func (fa *FearfulAgent) GetTargetPosition() (infra.PositionVector, bool) {
-	// occupied := grid.GetAllOccupiedAgentPositions()
-
-	var closestInCluster infra.IExtendedAgent = nil
-	minDist := math.Inf(1)
-
-	for otherID, otherAgent := range fa.GetAgentMap() {
-		// Ignore agents outside of cluster
-		if otherAgent.GetClusterID() != fa.clusterID {
-			continue
-		}
-
-		// Ignore self
-		if otherID == fa.GetID() {
-			continue
-		}
-
-		dist := fa.position.Dist(otherAgent.GetPosition())
-		if dist < minDist {
-			minDist = dist
-			closestInCluster = otherAgent
-		}
-	}
-
-	if closestInCluster == nil {
+	closestInCluster, ok := fa.closestClusterMate()
+	if !ok {
 		return infra.PositionVector{}, false
 	}
 
@@ -73,5 +51,5 @@ func (fa *FearfulAgent) GetTargetPosition() (infra.PositionVector, bool) {
 
 	// closest->self + self == self - closest + self
 
-	return selfPos.Sub(closestPos).Add(selfPos), true
+	return selfPos.Add(fa.GetGrid().Displacement(closestPos, selfPos)), true
 }
@@ -2,7 +2,6 @@ package agents
 
 import (
 	"fmt"
-	"math"
 
 	"github.com/aaashah/TMT_FYP/infra"
 )
@@ -12,21 +11,22 @@ type PreoccupiedAgent struct {
 }
 
 func CreatePreoccupiedAgent(server infra.IServer) *PreoccupiedAgent {
-	worldview := infra.NewWorldview(byte(0b10))
+	worldview := infra.NewWorldview(byte(0b10), server.GetRNG())
 	extendedAgent := CreateExtendedAgent(server, worldview)
 
 	// Set Preoccupied-style attachment: high anxiety, low avoidance
 	extendedAgent.attachment = infra.Attachment{
-		Anxiety:   randInRange(0.5, 1.0),
-		Avoidance: randInRange(0.0, 0.5),
+		Anxiety:   extendedAgent.randInRange(0.5, 1.0),
+		Avoidance: extendedAgent.randInRange(0.0, 0.5),
 		Type:      infra.PREOCCUPIED,
 	}
+	extendedAgent.speed = server.GetSpeed(infra.PREOCCUPIED)
 	// these ranges to be tweaked
 	extendedAgent.PTW = infra.PTSParams{
-		CheckProb: randInRange(0.5, 1.0),
-		ReplyProb: randInRange(0.5, 1.0),
-		Alpha:     randInRange(0.5, 1.0),
-		Beta:      randInRange(0.5, 1.0),
+		CheckProb: extendedAgent.randInRange(0.5, 1.0),
+		ReplyProb: extendedAgent.randInRange(0.5, 1.0),
+		Alpha:     extendedAgent.randInRange(0.5, 1.0),
+		Beta:      extendedAgent.randInRange(0.5, 1.0),
 	}
 
 	return &PreoccupiedAgent{
@@ -42,30 +42,8 @@ func (pa *PreoccupiedAgent) AgentInitialised() {
 // preoccupied agent movement policy
 // Moves towards closest in cluster
 func (pa *PreoccupiedAgent) GetTargetPosition() (infra.PositionVector, bool) {
-	// occupied := grid.GetAllOccupiedAgentPositions()
-
-	var closestInCluster infra.IExtendedAgent = nil
-	minDist := math.Inf(1)
-
-	for otherID, otherAgent := range pa.GetAgentMap() {
-		// Ignore agents outside of cluster
-		if otherAgent.GetClusterID() != pa.clusterID {
-			continue
-		}
-
-		// Ignore self
-		if otherID == pa.GetID() {
-			continue
-		}
-
-		dist := pa.position.Dist(otherAgent.GetPosition())
-		if dist < minDist {
-			minDist = dist
-			closestInCluster = otherAgent
-		}
-	}
-
-	if closestInCluster == nil {
+	closestInCluster, ok := pa.closestClusterMate()
+	if !ok {
 		return infra.PositionVector{}, false
 	}
 
@@ -12,21 +12,22 @@ type SecureAgent struct {
 }
 
 func CreateSecureAgent(server infra.IServer) *SecureAgent {
-	worldview := infra.NewWorldview(byte(0b11))
+	worldview := infra.NewWorldview(byte(0b11), server.GetRNG())
 	extendedAgent := CreateExtendedAgent(server, worldview)
 
 	// Set Secure-style attachment: low anxiety, low avoidance
 	extendedAgent.attachment = infra.Attachment{
-		Anxiety:   randInRange(0.0, 0.5),
-		Avoidance: randInRange(0.0, 0.5),
+		Anxiety:   extendedAgent.randInRange(0.0, 0.5),
+		Avoidance: extendedAgent.randInRange(0.0, 0.5),
 		Type:      infra.SECURE,
 	}
+	extendedAgent.speed = server.GetSpeed(infra.SECURE)
 	// these ranges to be tweaked
 	extendedAgent.PTW = infra.PTSParams{
-		CheckProb: randInRange(0.0, 0.5),
-		ReplyProb: randInRange(0.5, 1.0),
-		Alpha:     randInRange(0.5, 1.0),
-		Beta:      randInRange(0.0, 0.5),
+		CheckProb: extendedAgent.randInRange(0.0, 0.5),
+		ReplyProb: extendedAgent.randInRange(0.5, 1.0),
+		Alpha:     extendedAgent.randInRange(0.5, 1.0),
+		Beta:      extendedAgent.randInRange(0.0, 0.5),
 	}
 
 	return &SecureAgent{
@@ -45,7 +46,7 @@ func (da *SecureAgent) GetTargetPosition() (infra.PositionVector, bool) {
 	var closestInNetwork infra.IExtendedAgent = nil
 	minDist := math.Inf(1)
 
-	for otherID := range da.network {
+	for _, otherID := range infra.SortedIDs(da.network) {
 		// Ignore self
 		if otherID == da.GetID() {
 			continue
@@ -58,7 +59,7 @@ func (da *SecureAgent) GetTargetPosition() (infra.PositionVector, bool) {
 			continue
 		}
 
-		dist := da.position.Dist(otherAgent.GetPosition())
+		dist := da.GetGrid().Dist(da.position, otherAgent.GetPosition())
 		if dist < minDist {
 			minDist = dist
 			closestInNetwork = otherAgent
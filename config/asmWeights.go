@@ -0,0 +1,128 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// attachmentStyles are the names used to key per-attachment overrides,
+// matching infra.AttachmentType.String()
+var attachmentStyles = []string{"Dismissive", "Fearful", "Preoccupied", "Secure"}
+
+// MortalitySalienceWeights weight the mortality salience sub-scores (W1-W4)
+type MortalitySalienceWeights struct {
+	ClusterEliminations float64 `json:"ClusterEliminations"`
+	NetworkEliminations float64 `json:"NetworkEliminations"`
+	RelativeAge         float64 `json:"RelativeAge"`
+	MemorialProximity   float64 `json:"MemorialProximity"`
+}
+
+// WorldviewValidationWeights weight the worldview validation sub-scores (W5-W7)
+type WorldviewValidationWeights struct {
+	ClusterProfile float64 `json:"ClusterProfile"`
+	NetworkProfile float64 `json:"NetworkProfile"`
+	Ysterofimia    float64 `json:"Ysterofimia"`
+}
+
+// RelationshipValidationWeights weight the relationship validation sub-scores (W8-W10)
+type RelationshipValidationWeights struct {
+	Estrangement    float64 `json:"Estrangement"`
+	ProSocialEsteem float64 `json:"ProSocialEsteem"`
+	HeroismTendency float64 `json:"HeroismTendency"`
+}
+
+// ASMWeights weight the sub-scores within each ASM module. Each module's weights sum to 1.
+type ASMWeights struct {
+	MortalitySalience      MortalitySalienceWeights      `json:"MortalitySalience"`
+	WorldviewValidation    WorldviewValidationWeights    `json:"WorldviewValidation"`
+	RelationshipValidation RelationshipValidationWeights `json:"RelationshipValidation"`
+}
+
+// ModuleWeights combine the three module scores into one for the weighted decision rules
+type ModuleWeights struct {
+	MortalitySalience      float64 `json:"MortalitySalience"`
+	WorldviewValidation    float64 `json:"WorldviewValidation"`
+	RelationshipValidation float64 `json:"RelationshipValidation"`
+}
+
+// ASMWeightOverride replaces whole modules of the ASM weights for one attachment
+// style. Modules left out keep the base weights.
+type ASMWeightOverride struct {
+	MortalitySalience      *MortalitySalienceWeights      `json:"MortalitySalience,omitempty"`
+	WorldviewValidation    *WorldviewValidationWeights    `json:"WorldviewValidation,omitempty"`
+	RelationshipValidation *RelationshipValidationWeights `json:"RelationshipValidation,omitempty"`
+}
+
+// WeightsFor returns the ASM weights used by agents of the given attachment style
+func (cfg Config) WeightsFor(attachmentStyle string) ASMWeights {
+	weights := cfg.ASMWeights
+	override, ok := cfg.AttachmentASMWeights[attachmentStyle]
+	if !ok {
+		return weights
+	}
+	if override.MortalitySalience != nil {
+		weights.MortalitySalience = *override.MortalitySalience
+	}
+	if override.WorldviewValidation != nil {
+		weights.WorldviewValidation = *override.WorldviewValidation
+	}
+	if override.RelationshipValidation != nil {
+		weights.RelationshipValidation = *override.RelationshipValidation
+	}
+	return weights
+}
+
+func (w MortalitySalienceWeights) validate() error {
+	return validateModule("mortality salience", w.ClusterEliminations, w.NetworkEliminations, w.RelativeAge, w.MemorialProximity)
+}
+
+func (w WorldviewValidationWeights) validate() error {
+	return validateModule("worldview validation", w.ClusterProfile, w.NetworkProfile, w.Ysterofimia)
+}
+
+func (w RelationshipValidationWeights) validate() error {
+	return validateModule("relationship validation", w.Estrangement, w.ProSocialEsteem, w.HeroismTendency)
+}
+
+func (w ModuleWeights) validate() error {
+	return validateModule("decision module", w.MortalitySalience, w.WorldviewValidation, w.RelationshipValidation)
+}
+
+func (w ASMWeights) validate() error {
+	return errors.Join(w.MortalitySalience.validate(), w.WorldviewValidation.validate(), w.RelationshipValidation.validate())
+}
+
+func validateModule(module string, weights ...float64) error {
+	epsilon := 1e-6
+	sum := 0.0
+	for _, weight := range weights {
+		if weight < 0 {
+			return fmt.Errorf("%s weights cannot be negative", module)
+		}
+		sum += weight
+	}
+	if math.Abs(sum-1) > epsilon {
+		return fmt.Errorf("%s weights sum to %v, expected 1", module, sum)
+	}
+	return nil
+}
+
+func (cfg Config) validateASMWeights() error {
+	if err := cfg.ASMWeights.validate(); err != nil {
+		return err
+	}
+	for style := range cfg.AttachmentASMWeights {
+		known := false
+		for _, name := range attachmentStyles {
+			known = known || name == style
+		}
+		if !known {
+			return fmt.Errorf("unknown attachment style %q in ASM weight overrides", style)
+		}
+		if err := cfg.WeightsFor(style).validate(); err != nil {
+			return fmt.Errorf("%s ASM weights: %w", style, err)
+		}
+	}
+	return nil
+}
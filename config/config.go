@@ -1,62 +1,400 @@
 package config
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"flag"
+	"fmt"
 	"math"
+	"os"
 )
 
 type Config struct {
-	GridWidth               int     `json:"GridWidth"`
-	GridHeight              int     `json:"GridHeight"`
-	NumAgents               int     `json:"NumAgents"`
-	DismissiveProp          float64 `json:"DismissiveProp"`
-	FearfulProp             float64 `json:"FearfulProp"`
-	PreoccupiedProp         float64 `json:"ProccupiedProp"`
-	SecureProp              float64 `json:"SecureProp"`
-	NumIterations           int     `json:"NumIterations"`
-	NumTurns                int     `json:"NumTurns"`
-	NumClusters             int     `json:"NumClusters"`
-	ConnectionProbability   float64 `json:"ConnectionProb"`
-	PopulationRho           float64 `json:"PopulationRho"`
-	InitialExpectedChildren float64 `json:"InitialExpectedChildren"`
-	MinExpectedChildren     float64 `json:"MinExpectedChildren"`
-	MaxExpectedChildren     float64 `json:"MaxExpectedChildren"`
-	MutationRate            float64 `json:"Mu"`
-	ASMThreshold            float64 `json:"ASMThreshold"`
-	Debug                   bool    `json:"-"`
-	Seed                    int64   `json:"-"`
+	GridWidth               int                          `json:"GridWidth"`
+	GridHeight              int                          `json:"GridHeight"`
+	Topology                string                       `json:"Topology"`
+	Neighbourhood           string                       `json:"Neighbourhood"`
+	Speeds                  Speeds                       `json:"Speeds"`
+	MemorialPathCost        float64                      `json:"MemorialPathCost"`
+	PathSearchLimit         int                          `json:"PathSearchLimit"`
+	MemorialLifetime        int                          `json:"MemorialLifetime"`
+	MemorialDecay           float64                      `json:"MemorialDecay"`
+	PermanentTemples        bool                         `json:"PermanentTemples"`
+	ProximityRadius         float64                      `json:"ProximityRadius"`
+	Resources               bool                         `json:"Resources"`
+	FoodCapacity            float64                      `json:"FoodCapacity"`
+	FoodRegrowth            float64                      `json:"FoodRegrowth"`
+	InitialEnergy           float64                      `json:"InitialEnergy"`
+	MaxEnergy               float64                      `json:"MaxEnergy"`
+	Metabolism              float64                      `json:"Metabolism"`
+	ScarcityRho             bool                         `json:"ScarcityRho"`
+	SpawnStrategy           string                       `json:"SpawnStrategy"`
+	SpawnRadius             float64                      `json:"SpawnRadius"`
+	SpawnZoneX              int                          `json:"SpawnZoneX"`
+	SpawnZoneY              int                          `json:"SpawnZoneY"`
+	NumAgents               int                          `json:"NumAgents"`
+	DismissiveProp          float64                      `json:"DismissiveProp"`
+	FearfulProp             float64                      `json:"FearfulProp"`
+	PreoccupiedProp         float64                      `json:"PreoccupiedProp"`
+	SecureProp              float64                      `json:"SecureProp"`
+	NumIterations           int                          `json:"NumIterations"`
+	NumTurns                int                          `json:"NumTurns"`
+	NumClusters             int                          `json:"NumClusters"`
+	KMeansRestarts          int                          `json:"KMeansRestarts"`
+	KMeansMaxIterations     int                          `json:"KMeansMaxIterations"`
+	KMeansTolerance         float64                      `json:"KMeansTolerance"`
+	ConnectionProbability   float64                      `json:"ConnectionProb"`
+	PopulationRho           float64                      `json:"PopulationRho"`
+	InitialExpectedChildren float64                      `json:"InitialExpectedChildren"`
+	MinExpectedChildren     float64                      `json:"MinExpectedChildren"`
+	MaxExpectedChildren     float64                      `json:"MaxExpectedChildren"`
+	MutationRate            float64                      `json:"Mu"`
+	ASMThreshold            float64                      `json:"ASMThreshold"`
+	RhoSchedule             *Schedule                    `json:"RhoSchedule,omitempty"` // scenario file only, overrides PopulationRho
+	TauSchedule             *Schedule                    `json:"TauSchedule,omitempty"` // scenario file only, overrides ASMThreshold
+	ASMWeights              ASMWeights                   `json:"ASMWeights"`
+	AttachmentASMWeights    map[string]ASMWeightOverride `json:"AttachmentASMWeights,omitempty"` // keyed by attachment style, scenario file only
+	DecisionRule            string                       `json:"DecisionRule"`
+	DecisionWorkers         int                          `json:"DecisionWorkers"`
+	ModuleWeights           ModuleWeights                `json:"ModuleWeights"`
+	DecisionTemperature     float64                      `json:"DecisionTemperature"`
+	DecisionMargin          float64                      `json:"DecisionMargin"`
+	InactionPolicy          string                       `json:"InactionPolicy"`
+	EliminationPolicy       string                       `json:"EliminationPolicy"`
+	PunishmentMultiplier    float64                      `json:"PunishmentMultiplier"`
+	ClusterQuotas           bool                         `json:"ClusterQuotas"`
+	Events                  []Event                      `json:"Events,omitempty"` // scenario timeline, scenario file only
+	Debug                   bool                         `json:"Debug"`
+	Seed                    int64                        `json:"Seed"`
+	OutputDir               string                       `json:"-"` // where run directories are created, not a model parameter
+	FlushPolicy             string                       `json:"-"`
+	Fsync                   bool                         `json:"-"`
+	NetworkSnapshotEvery    int                          `json:"-"` // 0 disables social network snapshots
+	CheckpointEvery         int                          `json:"-"` // 0 disables checkpoints
+	Resume                  string                       `json:"-"` // checkpoint to continue from, empty for a fresh run
+}
+
+// bindFlags registers every Config field on fs, writing defaults into cfg
+func bindFlags(fs *flag.FlagSet, cfg *Config) {
+	fs.IntVar(&cfg.GridWidth, "width", 50, "Width of Grid World")
+	fs.IntVar(&cfg.GridHeight, "height", 50, "Height of Grid World")
+	fs.StringVar(&cfg.Topology, "topology", "bounded", "Grid topology: bounded or toroidal")
+	fs.StringVar(&cfg.Neighbourhood, "neighbourhood", "vonNeumann", "Cells an agent can step to: vonNeumann, moore or hex")
+	fs.IntVar(&cfg.Speeds.Dismissive, "speed_dismissive", 2, "Steps per turn for dismissive agents")
+	fs.IntVar(&cfg.Speeds.Fearful, "speed_fearful", 1, "Steps per turn for fearful agents")
+	fs.IntVar(&cfg.Speeds.Preoccupied, "speed_preoccupied", 2, "Steps per turn for preoccupied agents")
+	fs.IntVar(&cfg.Speeds.Secure, "speed_secure", 1, "Steps per turn for secure agents")
+	fs.Float64Var(&cfg.MemorialPathCost, "memorialCost", 0, "Extra path cost of each step onto a cell next to a tombstone or temple")
+	fs.IntVar(&cfg.PathSearchLimit, "pathSearchLimit", 500, "Cells a path search expands before heading for the closest cell found")
+	fs.IntVar(&cfg.MemorialLifetime, "memorialLifetime", 0, "Iterations a tombstone or temple stands before it is removed (0: forever)")
+	fs.Float64Var(&cfg.MemorialDecay, "memorialDecay", 0, "Fraction of its salience a tombstone or temple loses each iteration")
+	fs.BoolVar(&cfg.PermanentTemples, "permanentTemples", false, "Keep temples at full salience forever, so only tombstones fade")
+	fs.Float64Var(&cfg.ProximityRadius, "proximityRadius", 10, "Only memorials and cluster members this close count towards memorial proximity (0: the whole grid)")
+	fs.BoolVar(&cfg.Resources, "resources", false, "Put food on the grid, which agents must eat or starve")
+	fs.Float64Var(&cfg.FoodCapacity, "foodCapacity", 1, "Most food a cell can hold")
+	fs.Float64Var(&cfg.FoodRegrowth, "foodRegrowth", 0.02, "Fraction of its capacity a cell regrows each turn")
+	fs.Float64Var(&cfg.InitialEnergy, "initialEnergy", 10, "Energy an agent is born with")
+	fs.Float64Var(&cfg.MaxEnergy, "maxEnergy", 20, "Most energy an agent can store")
+	fs.Float64Var(&cfg.Metabolism, "metabolism", 0.2, "Energy an agent burns each turn")
+	fs.BoolVar(&cfg.ScarcityRho, "scarcityRho", false, "Set rho each iteration from food scarcity instead of -rho (needs -resources)")
+	fs.StringVar(&cfg.SpawnStrategy, "spawn", "random", "Where new agents are placed: random, parent, centroid or zone")
+	fs.Float64Var(&cfg.SpawnRadius, "spawnRadius", 2, "How far from its parent, cluster centroid or zone centre an agent may be placed")
+	fs.IntVar(&cfg.SpawnZoneX, "spawnX", -1, "X of the spawn zone's centre (-1: centre of the grid)")
+	fs.IntVar(&cfg.SpawnZoneY, "spawnY", -1, "Y of the spawn zone's centre (-1: centre of the grid)")
+	fs.IntVar(&cfg.NumAgents, "numAgents", 40, "Initial number of agents")
+	fs.Float64Var(&cfg.DismissiveProp, "dismissive", 0.25, "Initial proportion of dismissive agents")
+	fs.Float64Var(&cfg.FearfulProp, "fearful", 0.25, "Initial proportion of fearful agents")
+	fs.Float64Var(&cfg.PreoccupiedProp, "preoccupied", 0.25, "Initial proportion of preoccupied agents")
+	fs.Float64Var(&cfg.SecureProp, "secure", 0.25, "Initial proportion of secure agents")
+	fs.IntVar(&cfg.NumIterations, "iters", 100, "Number of iterations")
+	fs.IntVar(&cfg.NumTurns, "turns", 50, "Initial number of turns")
+	fs.IntVar(&cfg.NumClusters, "kappa", 3, "Number of agent clusters")
+	fs.IntVar(&cfg.KMeansRestarts, "kmeansRestarts", 5, "k-means runs per clustering, keeping the one with the lowest inertia")
+	fs.IntVar(&cfg.KMeansMaxIterations, "kmeansMaxIters", 100, "Most iterations of each k-means run")
+	fs.Float64Var(&cfg.KMeansTolerance, "kmeansTolerance", 1e-4, "Largest centroid shift, in cells, at which k-means counts as converged")
+	fs.Float64Var(&cfg.ConnectionProbability, "connectionProb", 0.35, "Probability of connections in social network")
+	fs.Float64Var(&cfg.PopulationRho, "rho", 0.2, "Proportion of population required to self-sacrifice")
+	fs.Float64Var(&cfg.InitialExpectedChildren, "init_r0", 2.0, "Initial R0 of population")
+	fs.Float64Var(&cfg.MinExpectedChildren, "min_r0", 1.9, "Minimum R0 of population")
+	fs.Float64Var(&cfg.MaxExpectedChildren, "max_r0", 2.1, "Maximum R0 of population")
+	fs.Float64Var(&cfg.MutationRate, "mu", 0.2, "Mutation rate of spawned children")
+	fs.Float64Var(&cfg.ASMThreshold, "tau", 0.5, "Threshold for ASM decision")
+	fs.Float64Var(&cfg.ASMWeights.MortalitySalience.ClusterEliminations, "w_ce", 0.25, "Weight of cluster eliminations in mortality salience")
+	fs.Float64Var(&cfg.ASMWeights.MortalitySalience.NetworkEliminations, "w_ne", 0.25, "Weight of network eliminations in mortality salience")
+	fs.Float64Var(&cfg.ASMWeights.MortalitySalience.RelativeAge, "w_ra", 0.25, "Weight of relative age in mortality salience")
+	fs.Float64Var(&cfg.ASMWeights.MortalitySalience.MemorialProximity, "w_mp", 0.25, "Weight of memorial proximity in mortality salience")
+	fs.Float64Var(&cfg.ASMWeights.WorldviewValidation.ClusterProfile, "w_cpr", 0.33, "Weight of cluster profile in worldview validation")
+	fs.Float64Var(&cfg.ASMWeights.WorldviewValidation.NetworkProfile, "w_npr", 0.33, "Weight of network profile in worldview validation")
+	fs.Float64Var(&cfg.ASMWeights.WorldviewValidation.Ysterofimia, "w_ysterofimia", 0.34, "Weight of ysterofimia in worldview validation")
+	fs.Float64Var(&cfg.ASMWeights.RelationshipValidation.Estrangement, "w_est", 0.33, "Weight of estrangement in relationship validation")
+	fs.Float64Var(&cfg.ASMWeights.RelationshipValidation.ProSocialEsteem, "w_pse", 0.33, "Weight of pro-social esteem in relationship validation")
+	fs.Float64Var(&cfg.ASMWeights.RelationshipValidation.HeroismTendency, "w_heroism", 0.34, "Weight of heroism tendency in relationship validation")
+	fs.StringVar(&cfg.DecisionRule, "rule", "vote", "ASM decision rule: vote, weighted, logistic, softmax or margin")
+	fs.IntVar(&cfg.DecisionWorkers, "decisionWorkers", 1, "Goroutines making ASM decisions concurrently (1: decide one agent at a time)")
+	fs.Float64Var(&cfg.ModuleWeights.MortalitySalience, "w_ms", 0.33, "Weight of mortality salience in the weighted decision rules")
+	fs.Float64Var(&cfg.ModuleWeights.WorldviewValidation, "w_wv", 0.33, "Weight of worldview validation in the weighted decision rules")
+	fs.Float64Var(&cfg.ModuleWeights.RelationshipValidation, "w_rv", 0.34, "Weight of relationship validation in the weighted decision rules")
+	fs.Float64Var(&cfg.DecisionTemperature, "temperature", 0.1, "Temperature of the logistic and softmax decision rules")
+	fs.Float64Var(&cfg.DecisionMargin, "margin", 0.05, "Half-width of the band around tau treated as inaction by the margin rule")
+	fs.StringVar(&cfg.InactionPolicy, "inaction", "refuse", "How abstaining agents are treated: refuse, first or reconsider")
+	fs.StringVar(&cfg.EliminationPolicy, "elimination", "multiplier", "How unmet sacrifice quotas are filled: multiplier, ageLottery, esteemLottery, cluster, centrality or none")
+	fs.Float64Var(&cfg.PunishmentMultiplier, "punishment", 2.0, "Non-volunteers eliminated per missing volunteer")
+	fs.BoolVar(&cfg.ClusterQuotas, "clusterQuotas", false, "Give each k-means cluster its own sacrifice quota and R0")
+	fs.BoolVar(&cfg.Debug, "debug", false, "Log debug messages to console")
+	fs.Int64Var(&cfg.Seed, "seed", 42, "Random seed for reproducibility")
+	fs.StringVar(&cfg.OutputDir, "out", "JSONlogs", "Directory in which each run's log directory is created")
+	fs.StringVar(&cfg.FlushPolicy, "flush", "iteration", "How often log lines are flushed to disk: turn, iteration or end")
+	fs.BoolVar(&cfg.Fsync, "fsync", false, "fsync the log file after every flush")
+	fs.IntVar(&cfg.NetworkSnapshotEvery, "networkEvery", 0, "Write a GraphML snapshot of the social network every N iterations (0 disables)")
+	fs.IntVar(&cfg.CheckpointEvery, "checkpointEvery", 0, "Write a checkpoint of the full simulation state every N iterations (0 disables)")
+	fs.StringVar(&cfg.Resume, "resume", "", "Path to a checkpoint to resume from (its config is used unless overridden)")
+}
+
+// DefaultConfig returns a Config holding the default value of every flag
+func DefaultConfig() Config {
+	cfg := Config{}
+	bindFlags(flag.NewFlagSet("defaults", flag.ContinueOnError), &cfg)
+	return cfg
 }
 
 // NewConfig parses the command line and returns a populated Config
 func NewConfig() Config {
+	cfg, err := Load(os.Args[0], os.Args[1:], flag.ExitOnError)
+	if err != nil {
+		panic(err)
+	}
+	return cfg
+}
+
+// Load parses args into a Config. If -config names a scenario file, the file
+// is applied over the defaults first and any flags set in args override it.
+// If -resume names a checkpoint, its config takes the place of the defaults,
+// so a scenario file or flags fork the checkpointed run with new parameters.
+func Load(name string, args []string, handling flag.ErrorHandling) (Config, error) {
 	cfg := Config{}
+	fs := flag.NewFlagSet(name, handling)
+	bindFlags(fs, &cfg)
+	scenarioPath := fs.String("config", "", "Path to a JSON scenario file (flags override its values)")
+
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	if *scenarioPath != "" || cfg.Resume != "" {
+		fileCfg := DefaultConfig()
+		if cfg.Resume != "" {
+			checkpointCfg, err := ReadCheckpointConfig(cfg.Resume)
+			if err != nil {
+				return Config{}, err
+			}
+			fileCfg = checkpointCfg
+		}
+		if *scenarioPath != "" {
+			scenarioCfg, err := readScenarioFileOver(*scenarioPath, fileCfg)
+			if err != nil {
+				return Config{}, err
+			}
+			fileCfg = scenarioCfg
+		}
+		// re-apply only the flags given explicitly on the command line
+		var setErr error
+		fs.Visit(func(f *flag.Flag) {
+			if f.Name == "config" || setErr != nil {
+				return
+			}
+			setErr = fileCfg.Set(f.Name, f.Value.String())
+		})
+		if setErr != nil {
+			return Config{}, setErr
+		}
+		cfg = fileCfg
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
 
-	flag.IntVar(&cfg.GridWidth, "width", 50, "Width of Grid World")
-	flag.IntVar(&cfg.GridHeight, "height", 50, "Height of Grid World")
-	flag.IntVar(&cfg.NumAgents, "numAgents", 40, "Initial number of agents")
-	flag.Float64Var(&cfg.DismissiveProp, "dismissive", 0.25, "Initial proportion of dismissive agents")
-	flag.Float64Var(&cfg.FearfulProp, "fearful", 0.25, "Initial proportion of fearful agents")
-	flag.Float64Var(&cfg.PreoccupiedProp, "preoccupied", 0.25, "Initial proportion of preoccupied agents")
-	flag.Float64Var(&cfg.SecureProp, "secure", 0.25, "Initial proportion of secure agents")
-	flag.IntVar(&cfg.NumIterations, "iters", 100, "Number of iterations")
-	flag.IntVar(&cfg.NumTurns, "turns", 50, "Initial number of turns")
-	flag.IntVar(&cfg.NumClusters, "kappa", 3, "Number of agent clusters")
-	flag.Float64Var(&cfg.ConnectionProbability, "connectionProb", 0.35, "Probability of connections in social network")
-	flag.Float64Var(&cfg.PopulationRho, "rho", 0.2, "Proportion of population required to self-sacrifice")
-	flag.Float64Var(&cfg.InitialExpectedChildren, "init_r0", 2.0, "Initial R0 of population")
-	flag.Float64Var(&cfg.MinExpectedChildren, "min_r0", 1.9, "Minimum R0 of population")
-	flag.Float64Var(&cfg.MaxExpectedChildren, "max_r0", 2.1, "Maximum R0 of population")
-	flag.Float64Var(&cfg.MutationRate, "mu", 0.2, "Mutation rate of spawned children")
-	flag.Float64Var(&cfg.ASMThreshold, "tau", 0.5, "Threshold for ASM decision")
-	flag.BoolVar(&cfg.Debug, "debug", false, "Log debug messages to console")
-	flag.Int64Var(&cfg.Seed, "seed", 42, "Random seed for reproducibility")
-
-	flag.Parse()
+// Set assigns a single field by its flag name, e.g. cfg.Set("rho", "0.3")
+func (cfg *Config) Set(name, value string) error {
+	current := *cfg
+	fs := flag.NewFlagSet("set", flag.ContinueOnError)
+	bindFlags(fs, cfg) // binding resets fields to defaults...
+	*cfg = current     // ...so restore the current values
+	if fs.Lookup(name) == nil {
+		return fmt.Errorf("unknown config parameter %q", name)
+	}
+	return fs.Set(name, value)
+}
+
+// ReadScenarioFile decodes a scenario file over the default Config. The keys
+// match the Config line of output.ndjson; unknown keys are rejected.
+func ReadScenarioFile(path string) (Config, error) {
+	return readScenarioFileOver(path, DefaultConfig())
+}
+
+func readScenarioFileOver(path string, cfg Config) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read scenario file: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("invalid scenario file %s: %w", path, err)
+	}
+	if decoder.More() {
+		return Config{}, fmt.Errorf("invalid scenario file %s: trailing data after config object", path)
+	}
+	return cfg, nil
+}
+
+// ReadCheckpointConfig returns the Config a checkpoint was written with. A
+// checkpoint stores it under its Config key, in the scenario file format.
+func ReadCheckpointConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	var checkpoint struct {
+		Config json.RawMessage
+	}
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return Config{}, fmt.Errorf("invalid checkpoint %s: %w", path, err)
+	}
+	if checkpoint.Config == nil {
+		return Config{}, fmt.Errorf("invalid checkpoint %s: no Config", path)
+	}
+	cfg := DefaultConfig()
+	decoder := json.NewDecoder(bytes.NewReader(checkpoint.Config))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("invalid checkpoint %s: %w", path, err)
+	}
+	cfg.Resume = path
+	return cfg, nil
+}
 
+// Validate checks that the Config describes a runnable simulation
+func (cfg Config) Validate() error {
 	epsilon := 0.05
 	if math.Abs(cfg.DismissiveProp+cfg.FearfulProp+cfg.PreoccupiedProp+cfg.SecureProp-1) > epsilon {
-		panic("Proportion of attachment types do not sum to 1.0")
+		return errors.New("proportion of attachment types do not sum to 1.0")
 	}
-
-	return cfg
+	switch cfg.Topology {
+	case "bounded", "toroidal":
+	default:
+		return fmt.Errorf("unknown topology %q, expected bounded or toroidal", cfg.Topology)
+	}
+	switch cfg.Neighbourhood {
+	case "vonNeumann", "moore":
+	case "hex":
+		// odd rows are shifted right, so wrapping needs an even number of rows
+		if cfg.Topology == "toroidal" && cfg.GridHeight%2 != 0 {
+			return errors.New("a toroidal hex grid needs an even height")
+		}
+	default:
+		return fmt.Errorf("unknown neighbourhood %q, expected vonNeumann, moore or hex", cfg.Neighbourhood)
+	}
+	if err := cfg.Speeds.validate(); err != nil {
+		return err
+	}
+	if cfg.MemorialPathCost < 0 {
+		return errors.New("memorial path cost cannot be negative")
+	}
+	if cfg.PathSearchLimit < 1 {
+		return errors.New("path search limit must be at least 1")
+	}
+	if cfg.MemorialLifetime < 0 {
+		return errors.New("memorial lifetime cannot be negative")
+	}
+	if cfg.MemorialDecay < 0 || cfg.MemorialDecay > 1 {
+		return errors.New("memorial decay must be between 0 and 1")
+	}
+	if cfg.ProximityRadius < 0 {
+		return errors.New("proximity radius cannot be negative")
+	}
+	if cfg.NumClusters < 1 {
+		return errors.New("there must be at least one cluster")
+	}
+	if cfg.KMeansRestarts < 1 || cfg.KMeansMaxIterations < 1 {
+		return errors.New("k-means needs at least one restart and one iteration")
+	}
+	if cfg.KMeansTolerance < 0 {
+		return errors.New("k-means tolerance cannot be negative")
+	}
+	if err := cfg.validateResources(); err != nil {
+		return err
+	}
+	if cfg.NumAgents > cfg.GridWidth*cfg.GridHeight {
+		return errors.New("more agents than grid cells")
+	}
+	switch cfg.SpawnStrategy {
+	case "random", "parent", "centroid", "zone":
+	default:
+		return fmt.Errorf("unknown spawn strategy %q, expected random, parent, centroid or zone", cfg.SpawnStrategy)
+	}
+	if cfg.SpawnRadius < 0 {
+		return errors.New("spawn radius cannot be negative")
+	}
+	if cfg.SpawnZoneX >= cfg.GridWidth || cfg.SpawnZoneY >= cfg.GridHeight || cfg.SpawnZoneX < -1 || cfg.SpawnZoneY < -1 {
+		return errors.New("spawn zone centre is off the grid")
+	}
+	switch cfg.FlushPolicy {
+	case "turn", "iteration", "end":
+	default:
+		return fmt.Errorf("unknown flush policy %q, expected turn, iteration or end", cfg.FlushPolicy)
+	}
+	if err := cfg.validateASMWeights(); err != nil {
+		return err
+	}
+	switch cfg.DecisionRule {
+	case "vote", "weighted", "margin":
+	case "logistic", "softmax":
+		if cfg.DecisionTemperature <= 0 {
+			return errors.New("decision temperature must be positive")
+		}
+	default:
+		return fmt.Errorf("unknown decision rule %q, expected vote, weighted, logistic, softmax or margin", cfg.DecisionRule)
+	}
+	if cfg.DecisionWorkers < 1 {
+		return errors.New("there must be at least one decision worker")
+	}
+	if err := cfg.ModuleWeights.validate(); err != nil {
+		return err
+	}
+	if cfg.DecisionMargin < 0 {
+		return errors.New("decision margin cannot be negative")
+	}
+	switch cfg.InactionPolicy {
+	case "refuse", "first", "reconsider":
+	default:
+		return fmt.Errorf("unknown inaction policy %q, expected refuse, first or reconsider", cfg.InactionPolicy)
+	}
+	switch cfg.EliminationPolicy {
+	case "multiplier", "ageLottery", "esteemLottery", "cluster", "centrality", "none":
+	default:
+		return fmt.Errorf("unknown elimination policy %q, expected multiplier, ageLottery, esteemLottery, cluster, centrality or none", cfg.EliminationPolicy)
+	}
+	if cfg.PunishmentMultiplier < 0 {
+		return errors.New("punishment multiplier cannot be negative")
+	}
+	if cfg.NetworkSnapshotEvery < 0 {
+		return errors.New("network snapshot interval cannot be negative")
+	}
+	if err := cfg.RhoSchedule.validate(); err != nil {
+		return fmt.Errorf("rho schedule: %w", err)
+	}
+	if err := cfg.TauSchedule.validate(); err != nil {
+		return fmt.Errorf("tau schedule: %w", err)
+	}
+	if err := cfg.validateEvents(); err != nil {
+		return err
+	}
+	if cfg.CheckpointEvery < 0 {
+		return errors.New("checkpoint interval cannot be negative")
+	}
+	return nil
 }
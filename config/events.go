@@ -0,0 +1,90 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Event types of a scenario timeline
+const (
+	EventCatastrophe  = "catastrophe"  // kills a random Fraction of a cluster at the end of the iteration
+	EventRho          = "rho"          // sets rho to Value
+	EventTau          = "tau"          // sets tau to Value
+	EventClearTemples = "clearTemples" // removes every temple from the grid
+	EventImmigration  = "immigration"  // adds Count agents of the given Attachment style
+)
+
+// Event is one entry of a scenario's timeline. Events are applied at the start
+// of their iteration, except catastrophes, which strike once the iteration's
+// clusters are known.
+type Event struct {
+	Iteration  int     `json:"Iteration"`
+	Type       string  `json:"Type"`
+	Value      float64 `json:"Value,omitempty"`      // rho, tau: the new value
+	Duration   int     `json:"Duration,omitempty"`   // rho, tau: iterations before the previous value returns, 0 for the rest of the run
+	Cluster    *int    `json:"Cluster,omitempty"`    // catastrophe: k-means cluster struck, the whole population if omitted
+	Fraction   float64 `json:"Fraction,omitempty"`   // catastrophe: proportion of the cluster killed
+	Count      int     `json:"Count,omitempty"`      // immigration: number of immigrants
+	Attachment string  `json:"Attachment,omitempty"` // immigration: attachment style of the immigrants
+}
+
+// ParameterAt returns the value of rho or tau in iteration iter: the latest
+// event of that type still in force, or base if there is none
+func (cfg Config) ParameterAt(eventType string, base float64, iter int) float64 {
+	value := base
+	latest := -1
+	for _, event := range cfg.Events {
+		if event.Type != eventType || event.Iteration > iter || event.Iteration < latest {
+			continue
+		}
+		if event.Duration > 0 && iter >= event.Iteration+event.Duration {
+			continue
+		}
+		value = event.Value
+		latest = event.Iteration
+	}
+	return value
+}
+
+func (event Event) validate(numClusters int) error {
+	if event.Iteration < 0 {
+		return errors.New("event iteration cannot be negative")
+	}
+	switch event.Type {
+	case EventRho, EventTau:
+		if event.Value < 0 || event.Value > 1 {
+			return fmt.Errorf("%s must be between 0 and 1", event.Type)
+		}
+		if event.Duration < 0 {
+			return fmt.Errorf("%s event duration cannot be negative", event.Type)
+		}
+	case EventCatastrophe:
+		if event.Fraction < 0 || event.Fraction > 1 {
+			return errors.New("catastrophe fraction must be between 0 and 1")
+		}
+		if event.Cluster != nil && (*event.Cluster < 0 || *event.Cluster >= numClusters) {
+			return fmt.Errorf("catastrophe cluster %d out of range, expected 0 to %d", *event.Cluster, numClusters-1)
+		}
+	case EventClearTemples:
+	case EventImmigration:
+		if event.Count <= 0 {
+			return errors.New("immigration count must be positive")
+		}
+		if !slices.Contains(attachmentStyles, event.Attachment) {
+			return fmt.Errorf("unknown immigrant attachment style %q", event.Attachment)
+		}
+	default:
+		return fmt.Errorf("unknown event type %q, expected catastrophe, rho, tau, clearTemples or immigration", event.Type)
+	}
+	return nil
+}
+
+func (cfg Config) validateEvents() error {
+	for i, event := range cfg.Events {
+		if err := event.validate(cfg.NumClusters); err != nil {
+			return fmt.Errorf("event %d: %w", i, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package config
+
+import "errors"
+
+func (cfg Config) validateResources() error {
+	if cfg.ScarcityRho && !cfg.Resources {
+		return errors.New("scarcity rho needs resources")
+	}
+	if cfg.ScarcityRho && cfg.RhoSchedule != nil {
+		return errors.New("rho cannot follow both a schedule and food scarcity")
+	}
+	if !cfg.Resources {
+		return nil
+	}
+	if cfg.FoodCapacity <= 0 {
+		return errors.New("food capacity must be positive")
+	}
+	if cfg.FoodRegrowth < 0 || cfg.FoodRegrowth > 1 {
+		return errors.New("food regrowth must be between 0 and 1")
+	}
+	if cfg.InitialEnergy <= 0 || cfg.MaxEnergy < cfg.InitialEnergy {
+		return errors.New("initial energy must be positive and no more than max energy")
+	}
+	if cfg.Metabolism < 0 {
+		return errors.New("metabolism cannot be negative")
+	}
+	return nil
+}
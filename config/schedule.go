@@ -0,0 +1,83 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Schedule makes rho or tau follow a curve over the iterations instead of
+// staying at its configured value
+type Schedule struct {
+	Type string `json:"Type"` // linear, step, sine or csv
+	// linear: From until StartIteration, then a straight ramp to To at EndIteration
+	StartIteration int     `json:"StartIteration,omitempty"`
+	EndIteration   int     `json:"EndIteration,omitempty"`
+	From           float64 `json:"From,omitempty"`
+	To             float64 `json:"To,omitempty"`
+	// step: each value holds from its iteration until the next step, the configured value before the first
+	Steps []ScheduleStep `json:"Steps,omitempty"`
+	// sine: Mean + Amplitude * sin(2*pi*(iteration + Phase) / Period)
+	Mean      float64 `json:"Mean,omitempty"`
+	Amplitude float64 `json:"Amplitude,omitempty"`
+	Period    float64 `json:"Period,omitempty"`
+	Phase     float64 `json:"Phase,omitempty"`
+	// csv: a file of iteration,value rows, read as steps
+	Path string `json:"Path,omitempty"`
+}
+
+type ScheduleStep struct {
+	Iteration int     `json:"Iteration"`
+	Value     float64 `json:"Value"`
+}
+
+func (s *Schedule) validate() error {
+	if s == nil {
+		return nil
+	}
+	switch s.Type {
+	case "linear":
+		if s.EndIteration < s.StartIteration {
+			return errors.New("linear schedule ends before it starts")
+		}
+		return checkUnitRange(s.From, s.To)
+	case "step":
+		if len(s.Steps) == 0 {
+			return errors.New("step schedule has no steps")
+		}
+		return ValidateSteps(s.Steps)
+	case "sine":
+		if s.Period <= 0 {
+			return errors.New("sine schedule period must be positive")
+		}
+		return checkUnitRange(s.Mean-s.Amplitude, s.Mean+s.Amplitude)
+	case "csv":
+		if s.Path == "" {
+			return errors.New("csv schedule has no path")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown schedule type %q, expected linear, step, sine or csv", s.Type)
+	}
+}
+
+// ValidateSteps checks that steps are in iteration order and hold values between 0 and 1
+func ValidateSteps(steps []ScheduleStep) error {
+	for i, step := range steps {
+		if i > 0 && step.Iteration <= steps[i-1].Iteration {
+			return errors.New("schedule steps must be in increasing iteration order")
+		}
+		if err := checkUnitRange(step.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkUnitRange(values ...float64) error {
+	for _, value := range values {
+		if value < 0 || value > 1 {
+			return fmt.Errorf("scheduled value %g is not between 0 and 1", value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package config
+
+import "errors"
+
+// Speeds is how many steps an agent of each attachment style takes per turn
+type Speeds struct {
+	Dismissive  int `json:"Dismissive"`
+	Fearful     int `json:"Fearful"`
+	Preoccupied int `json:"Preoccupied"`
+	Secure      int `json:"Secure"`
+}
+
+// SpeedFor returns the speed of the named attachment style
+func (s Speeds) SpeedFor(attachmentStyle string) int {
+	switch attachmentStyle {
+	case "Dismissive":
+		return s.Dismissive
+	case "Fearful":
+		return s.Fearful
+	case "Preoccupied":
+		return s.Preoccupied
+	default:
+		return s.Secure
+	}
+}
+
+func (s Speeds) validate() error {
+	if s.Dismissive < 1 || s.Fearful < 1 || s.Preoccupied < 1 || s.Secure < 1 {
+		return errors.New("every attachment style needs a speed of at least 1")
+	}
+	return nil
+}
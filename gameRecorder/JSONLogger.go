@@ -1,9 +1,12 @@
 package gameRecorder
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 
 	"github.com/aaashah/TMT_FYP/config"
 	"github.com/google/uuid"
@@ -25,10 +28,41 @@ type JSONAgentRecord struct {
 	Position            Position `json:"Position"`
 	Worldview           uint32   `json:"Worldview"`
 	Heroism             int      `json:"Heroism"`
-	//MortalitySalience      float32           `json:"MortalitySalience"`
-	//WorldviewValidation    float32           `json:"WorldviewValidation"`
-	//RelationshipValidation float32           `json:"RelationshipValidation"`
-	//ASMDecison		       string            `json:"ASMDecision"`
+	Parent1             string   `json:"Parent1,omitempty"`
+	Parent2             string   `json:"Parent2,omitempty"`
+	Generation          int      `json:"Generation"`
+	BirthIteration      int      `json:"BirthIteration"`
+	Energy              *float64 `json:"Energy,omitempty"` // only when the run has resources
+}
+
+type JSONMortalitySalience struct {
+	Score float32 `json:"Score"`
+	CE    float32 `json:"CE"`
+	NE    float32 `json:"NE"`
+	RA    float32 `json:"RA"`
+	MP    float32 `json:"MP"`
+}
+
+type JSONWorldviewValidation struct {
+	Score       float32 `json:"Score"`
+	CPR         float32 `json:"CPR"`
+	NPR         float32 `json:"NPR"`
+	Ysterofimia float32 `json:"Ysterofimia"`
+}
+
+type JSONRelationshipValidation struct {
+	Score           float32 `json:"Score"`
+	EST             float32 `json:"EST"`
+	PSE             float32 `json:"PSE"`
+	HeroismTendency float32 `json:"HeroismTendency"`
+}
+
+// JSONDecisionRecord holds an agent's ASM module scores, their inputs, and the resulting decision
+type JSONDecisionRecord struct {
+	MortalitySalience      JSONMortalitySalience      `json:"MortalitySalience"`
+	WorldviewValidation    JSONWorldviewValidation    `json:"WorldviewValidation"`
+	RelationshipValidation JSONRelationshipValidation `json:"RelationshipValidation"`
+	ASMDecision            string                     `json:"ASMDecision"`
 }
 
 type TurnJSONRecord struct {
@@ -37,49 +71,190 @@ type TurnJSONRecord struct {
 	EliminatedAgents          []string          `json:"EliminatedAgents"`
 	SelfSacrificedAgents      []string          `json:"EliminatedBySelfSacrifice"`
 	TotalVolunteers           int               `json:"NumVolunteers"`
+	TotalAbstentions          int               `json:"NumAbstentions"`
+	TotalRefusals             int               `json:"NumRefusals"`
 	TotalRequiredEliminations int               `json:"TotalRequiredEliminations"`
 	TombstoneLocations        []Position        `json:"TombstoneLocations"`
 	TempleLocations           []Position        `json:"TempleLocations"`
+	TombstoneAges             []int             `json:"TombstoneAges"` // in iterations, in the same order as the locations
+	TempleAges                []int             `json:"TempleAges"`
 }
 
 type IterationJSONRecord struct {
-	Iteration      int                   `json:"Iteration"`
-	Turns          []TurnJSONRecord      `json:"Turns"`
-	Thresholds     map[uuid.UUID]float64 `json:"AgentThresholds"`
-	NumberOfAgents int                   `json:"NumberOfAgents"`
+	Iteration      int                              `json:"Iteration"`
+	Thresholds     map[uuid.UUID]float64            `json:"AgentThresholds"`
+	Decisions      map[uuid.UUID]JSONDecisionRecord `json:"AgentDecisions"`
+	NumberOfAgents int                              `json:"NumberOfAgents"`
+	Clusters       []JSONClusterRecord              `json:"Clusters,omitempty"` // only with per-cluster quotas
+	Events         []JSONEventRecord                `json:"Events,omitempty"`   // scheduled events applied this iteration
+	Rho            float64                          `json:"Rho"`                // rho and tau in force this iteration
+	Tau            float64                          `json:"Tau"`
+	Resources      *JSONResourceRecord              `json:"Resources,omitempty"` // only when the run has resources
+	KMeans         *JSONKMeansRecord                `json:"KMeans,omitempty"`    // clustering applied this iteration
+}
+
+// JSONKMeansRecord is the k-means solution the iteration's clusters come from, indexed by cluster ID
+type JSONKMeansRecord struct {
+	Centroids  []JSONCentroid `json:"Centroids"`
+	Sizes      []int          `json:"Sizes"`
+	Inertia    float64        `json:"Inertia"`
+	Iterations int            `json:"Iterations"`
+}
+
+type JSONCentroid struct {
+	X float64 `json:"X"`
+	Y float64 `json:"Y"`
 }
 
-type GameJSONRecord struct {
-	Config     config.Config         `json:"Config"`
-	Iterations []IterationJSONRecord `json:"Iterations"`
+// JSONResourceRecord is the state of the food supply at the end of an iteration
+type JSONResourceRecord struct {
+	TotalFood  float64  `json:"TotalFood"`
+	MeanEnergy float64  `json:"MeanEnergy"`
+	Scarcity   float64  `json:"Scarcity"`
+	Starved    []string `json:"Starved"`
+}
+
+// JSONEventRecord is a timeline event as applied to the run
+type JSONEventRecord struct {
+	config.Event
+	Agents         []string `json:"Agents,omitempty"` // killed by a catastrophe or arriving as immigrants
+	TemplesRemoved int      `json:"TemplesRemoved,omitempty"`
+}
+
+// JSONClusterRecord is one cluster's sacrifice quota and R0 for an iteration
+type JSONClusterRecord struct {
+	ClusterID        int     `json:"ClusterID"`
+	Size             int     `json:"Size"`
+	Required         int     `json:"Required"`
+	Volunteers       int     `json:"Volunteers"`
+	MetQuota         bool    `json:"MetQuota"`
+	ExpectedChildren float64 `json:"ExpectedChildren"`
+}
+
+// FlushPolicy controls how often buffered log lines are pushed to the file
+type FlushPolicy string
+
+const (
+	FlushEveryTurn      FlushPolicy = "turn"
+	FlushEveryIteration FlushPolicy = "iteration"
+	FlushAtEnd          FlushPolicy = "end"
+)
+
+// Line types in the NDJSON log, stored under the "Type" key of every line
+const (
+	ConfigLine    = "Config"
+	TurnLine      = "Turn"
+	IterationLine = "Iteration"
+)
+
+type configLine struct {
+	Type   string        `json:"Type"`
+	Config config.Config `json:"Config"`
 }
 
-func (gjr *GameJSONRecord) AddIteration(record IterationJSONRecord) {
-	gjr.Iterations = append(gjr.Iterations, record)
+type turnLine struct {
+	Type      string `json:"Type"`
+	Iteration int    `json:"Iteration"`
+	TurnJSONRecord
 }
 
-func MakeGameRecord(config config.Config) *GameJSONRecord {
-	return &GameJSONRecord{
-		Config:     config,
-		Iterations: make([]IterationJSONRecord, 0),
+type iterationLine struct {
+	Type string `json:"Type"`
+	IterationJSONRecord
+}
+
+// StreamRecorder writes the game log as newline-delimited JSON while the simulation runs:
+// one Config line, then a Turn line per turn and an Iteration line closing each iteration.
+type StreamRecorder struct {
+	writer *bufio.Writer
+	file   *os.File // nil unless recording to a file, needed for fsync
+	flush  FlushPolicy
+	fsync  bool
+}
+
+func NewStreamRecorder(w io.Writer, flush FlushPolicy, fsync bool) *StreamRecorder {
+	file, _ := w.(*os.File)
+	return &StreamRecorder{
+		writer: bufio.NewWriter(w),
+		file:   file,
+		flush:  flush,
+		fsync:  fsync,
 	}
 }
 
-func WriteJSONLog(outputDir string, record *GameJSONRecord) error {
-	err := os.MkdirAll(outputDir, os.ModePerm)
+// CreateStreamFile opens outputDir/output.ndjson and writes the Config line
+func CreateStreamFile(outputDir string, cfg config.Config) (*StreamRecorder, error) {
+	file, err := os.Create(filepath.Join(outputDir, "output.ndjson"))
 	if err != nil {
-		return fmt.Errorf("failed to create output directory: %w", err)
+		return nil, fmt.Errorf("failed to create log file: %w", err)
+	}
+	recorder := NewStreamRecorder(file, FlushPolicy(cfg.FlushPolicy), cfg.Fsync)
+	if err := recorder.WriteConfig(cfg); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return recorder, nil
+}
+
+func (sr *StreamRecorder) WriteConfig(cfg config.Config) error {
+	if err := sr.writeLine(configLine{Type: ConfigLine, Config: cfg}); err != nil {
+		return err
+	}
+	return sr.Flush()
+}
+
+func (sr *StreamRecorder) WriteTurn(iteration int, record TurnJSONRecord) error {
+	checkForNaN("Turn", record)
+	if err := sr.writeLine(turnLine{Type: TurnLine, Iteration: iteration, TurnJSONRecord: record}); err != nil {
+		return err
 	}
+	if sr.flush == FlushEveryTurn {
+		return sr.Flush()
+	}
+	return nil
+}
 
-	checkForNaN("GameJSON", record)
+func (sr *StreamRecorder) WriteIteration(record IterationJSONRecord) error {
+	checkForNaN("Iteration", record)
+	if err := sr.writeLine(iterationLine{Type: IterationLine, IterationJSONRecord: record}); err != nil {
+		return err
+	}
+	if sr.flush == FlushEveryTurn || sr.flush == FlushEveryIteration {
+		return sr.Flush()
+	}
+	return nil
+}
 
-	fileName := fmt.Sprintf("%s/output.json", outputDir)
-	data, err := json.MarshalIndent(record, "", "  ")
+func (sr *StreamRecorder) writeLine(line any) error {
+	data, err := json.Marshal(line)
 	if err != nil {
-		return fmt.Errorf("error marshalling iteration JSON: %w", err)
+		return fmt.Errorf("error marshalling log line: %w", err)
 	}
+	data = append(data, '\n')
+	_, err = sr.writer.Write(data)
+	return err
+}
 
-	return os.WriteFile(fileName, data, 0644)
+// Flush pushes buffered lines to the underlying writer, syncing the file to disk if fsync is set
+func (sr *StreamRecorder) Flush() error {
+	if err := sr.writer.Flush(); err != nil {
+		return err
+	}
+	if sr.fsync && sr.file != nil {
+		return sr.file.Sync()
+	}
+	return nil
+}
+
+// Close flushes any remaining lines and closes the log file
+func (sr *StreamRecorder) Close() error {
+	err := sr.Flush()
+	if sr.file != nil {
+		if closeErr := sr.file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
 }
 
 func UUIDsToStrings(ids []uuid.UUID) []string {
@@ -0,0 +1,74 @@
+package gameRecorder
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// causes of death recorded in the lineage table
+const (
+	DeathNatural       = "Natural"
+	DeathSelfSacrifice = "SelfSacrifice"
+	DeathEliminated    = "Eliminated"
+	DeathCatastrophe   = "Catastrophe"
+	DeathStarvation    = "Starvation"
+)
+
+// LineageRecord is one agent of the run's genealogy, alive or dead
+type LineageRecord struct {
+	ID              string
+	AttachmentStyle string
+	Parent1         string // empty for founders
+	Parent2         string // equal to Parent1 for cloned children
+	Generation      int
+	BirthIteration  int    // -1 for founders
+	DeathIteration  int    // -1 if still alive at the end of the run
+	DeathCause      string // empty if still alive
+}
+
+// WriteLineageCSV writes lineage_agents.csv (one row per agent) and
+// lineage_edges.csv (one Parent,Child row per parent) into runDir
+func WriteLineageCSV(runDir string, records []LineageRecord) error {
+	agentRows := [][]string{{"ID", "AttachmentStyle", "Parent1", "Parent2", "Generation", "BirthIteration", "DeathIteration", "DeathCause"}}
+	edgeRows := [][]string{{"Parent", "Child"}}
+	for _, record := range records {
+		agentRows = append(agentRows, []string{
+			record.ID,
+			record.AttachmentStyle,
+			record.Parent1,
+			record.Parent2,
+			strconv.Itoa(record.Generation),
+			strconv.Itoa(record.BirthIteration),
+			strconv.Itoa(record.DeathIteration),
+			record.DeathCause,
+		})
+		if record.Parent1 != "" {
+			edgeRows = append(edgeRows, []string{record.Parent1, record.ID})
+		}
+		if record.Parent2 != "" && record.Parent2 != record.Parent1 {
+			edgeRows = append(edgeRows, []string{record.Parent2, record.ID})
+		}
+	}
+
+	if err := writeCSV(filepath.Join(runDir, "lineage_agents.csv"), agentRows); err != nil {
+		return err
+	}
+	return writeCSV(filepath.Join(runDir, "lineage_edges.csv"), edgeRows)
+}
+
+func writeCSV(path string, rows [][]string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Base(path), err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
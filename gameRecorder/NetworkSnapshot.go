@@ -0,0 +1,139 @@
+package gameRecorder
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// NetworkNode is one agent in a social network snapshot
+type NetworkNode struct {
+	ID              string
+	AttachmentStyle string
+	ClusterID       int
+	Age             int
+	Heroism         int
+}
+
+// NetworkEdge is a directed social tie weighted by the source's esteem for the target
+type NetworkEdge struct {
+	Source string
+	Target string
+	Weight float32
+}
+
+// NetworkSnapshot is the whole social network at the end of an iteration
+type NetworkSnapshot struct {
+	Iteration int
+	Nodes     []NetworkNode
+	Edges     []NetworkEdge
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Data        []graphMLData `xml:"data"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+var graphMLKeys = []graphMLKey{
+	{ID: "iteration", For: "graph", AttrName: "Iteration", AttrType: "int"},
+	{ID: "attachment", For: "node", AttrName: "AttachmentStyle", AttrType: "string"},
+	{ID: "cluster", For: "node", AttrName: "ClusterID", AttrType: "int"},
+	{ID: "age", For: "node", AttrName: "Age", AttrType: "int"},
+	{ID: "heroism", For: "node", AttrName: "Heroism", AttrType: "int"},
+	{ID: "weight", For: "edge", AttrName: "weight", AttrType: "double"},
+}
+
+// WriteGraphML writes snapshot as a directed GraphML graph, readable by Gephi and networkx
+func WriteGraphML(w io.Writer, snapshot NetworkSnapshot) error {
+	graph := graphMLGraph{
+		ID:          fmt.Sprintf("iteration_%d", snapshot.Iteration),
+		EdgeDefault: "directed",
+		Data:        []graphMLData{{Key: "iteration", Value: strconv.Itoa(snapshot.Iteration)}},
+		Nodes:       make([]graphMLNode, 0, len(snapshot.Nodes)),
+		Edges:       make([]graphMLEdge, 0, len(snapshot.Edges)),
+	}
+	for _, node := range snapshot.Nodes {
+		graph.Nodes = append(graph.Nodes, graphMLNode{
+			ID: node.ID,
+			Data: []graphMLData{
+				{Key: "attachment", Value: node.AttachmentStyle},
+				{Key: "cluster", Value: strconv.Itoa(node.ClusterID)},
+				{Key: "age", Value: strconv.Itoa(node.Age)},
+				{Key: "heroism", Value: strconv.Itoa(node.Heroism)},
+			},
+		})
+	}
+	for _, edge := range snapshot.Edges {
+		graph.Edges = append(graph.Edges, graphMLEdge{
+			Source: edge.Source,
+			Target: edge.Target,
+			Data:   []graphMLData{{Key: "weight", Value: strconv.FormatFloat(float64(edge.Weight), 'g', -1, 32)}},
+		})
+	}
+
+	document := graphMLDocument{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys:  graphMLKeys,
+		Graph: graph,
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return fmt.Errorf("error encoding network snapshot: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteNetworkSnapshot writes snapshot to <runDir>/networks/iteration_<N>.graphml
+func WriteNetworkSnapshot(runDir string, snapshot NetworkSnapshot) error {
+	networkDir := filepath.Join(runDir, "networks")
+	if err := os.MkdirAll(networkDir, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create network snapshot directory: %w", err)
+	}
+	file, err := os.Create(filepath.Join(networkDir, fmt.Sprintf("iteration_%04d.graphml", snapshot.Iteration)))
+	if err != nil {
+		return fmt.Errorf("failed to create network snapshot: %w", err)
+	}
+	defer file.Close()
+	return WriteGraphML(file, snapshot)
+}
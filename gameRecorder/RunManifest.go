@@ -0,0 +1,116 @@
+package gameRecorder
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/aaashah/TMT_FYP/config"
+)
+
+// RunManifest describes a single run and is written next to its logs
+type RunManifest struct {
+	RunID           string        `json:"RunID"`
+	Config          config.Config `json:"Config"`
+	Seed            int64         `json:"Seed"`
+	GitRevision     string        `json:"GitRevision,omitempty"`
+	ResumedFrom     string        `json:"ResumedFrom,omitempty"` // checkpoint the run continued from
+	StartTime       time.Time     `json:"StartTime"`
+	DurationSeconds float64       `json:"DurationSeconds"`
+	FinalPopulation int           `json:"FinalPopulation"`
+	Completed       bool          `json:"Completed"`
+	Error           string        `json:"Error,omitempty"`
+}
+
+func NewRunManifest(cfg config.Config) *RunManifest {
+	startTime := time.Now()
+	return &RunManifest{
+		RunID:       MakeRunID(cfg, startTime),
+		Config:      cfg,
+		Seed:        cfg.Seed,
+		GitRevision: GitRevision(),
+		StartTime:   startTime,
+	}
+}
+
+// Finish records the outcome of the run. failure is the recovered panic value, if any.
+func (rm *RunManifest) Finish(finalPopulation int, failure any) {
+	rm.DurationSeconds = time.Since(rm.StartTime).Seconds()
+	rm.FinalPopulation = finalPopulation
+	rm.Completed = failure == nil
+	if failure != nil {
+		rm.Error = fmt.Sprint(failure)
+	}
+}
+
+// MakeRunID builds an identifier of the form <timestamp>_seed<seed>_<config hash>
+func MakeRunID(cfg config.Config, startTime time.Time) string {
+	return fmt.Sprintf("%s_seed%d_%s", startTime.Format("20060102-150405"), cfg.Seed, ConfigHash(cfg))
+}
+
+// ConfigHash is a short digest of the config, equal for runs with identical parameters
+func ConfigHash(cfg config.Config) string {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		panic(err)
+	}
+	digest := sha256.Sum256(data)
+	return hex.EncodeToString(digest[:4])
+}
+
+// GitRevision returns the commit the binary was built from, or "" if unknown
+func GitRevision() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				return setting.Value
+			}
+		}
+	}
+	// `go run` does not stamp VCS info, so ask git directly
+	out, err := exec.Command("git", "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// MakeRunDirectory creates outputDir/runID, adding a suffix if that run directory already exists
+func MakeRunDirectory(outputDir, runID string) (string, error) {
+	err := os.MkdirAll(outputDir, os.ModePerm)
+	if err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+	runDir := filepath.Join(outputDir, runID)
+	for attempt := 2; ; attempt++ {
+		err := os.Mkdir(runDir, os.ModePerm)
+		if err == nil {
+			return runDir, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return "", fmt.Errorf("failed to create run directory: %w", err)
+		}
+		runDir = filepath.Join(outputDir, fmt.Sprintf("%s-%d", runID, attempt))
+	}
+}
+
+func WriteManifest(runDir string, manifest *RunManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling manifest: %w", err)
+	}
+	return os.WriteFile(filepath.Join(runDir, "manifest.json"), data, 0644)
+}
+
+// MarkLatestRun records the most recent run directory in outputDir/LATEST for the plotting scripts
+func MarkLatestRun(outputDir, runDir string) error {
+	return os.WriteFile(filepath.Join(outputDir, "LATEST"), []byte(filepath.Base(runDir)+"\n"), 0644)
+}
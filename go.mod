@@ -9,12 +9,14 @@ require (
 	github.com/google/uuid v1.6.0
 )
 
-require gonum.org/v1/gonum v0.16.0
+require (
+	github.com/stretchr/testify v1.10.0
+	gonum.org/v1/gonum v0.16.0
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/stretchr/objx v0.5.2 // indirect
-	github.com/stretchr/testify v1.10.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
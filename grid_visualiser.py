@@ -1,13 +1,12 @@
-import json
 import os
 import colorsys
 import plotly.graph_objects as go
 import dash
 from dash import dcc, html, callback_context
 from dash.dependencies import Input, Output, State
+from plots.run_logs import latest_output_path, load_run
 
 # --- Constants ---
-LOG_DIR = "JSONlogs/output.json"
 GRID_WIDTH = None
 GRID_HEIGHT = None
 CELL_SIZE = 30
@@ -19,8 +18,8 @@ max_iteration = -1
 turns_per_iteration = {}
 
 
-with open(LOG_DIR, "r") as file:
-    GAME_DATA = json.load(file)
+with open(latest_output_path(), "r") as file:
+    GAME_DATA = load_run(file)
     CONFIG = GAME_DATA["Config"]
     GRID_WIDTH = CONFIG["GridWidth"]
     GRID_HEIGHT = CONFIG["GridHeight"]
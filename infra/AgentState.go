@@ -0,0 +1,106 @@
+package infra
+
+import "github.com/google/uuid"
+
+// AgentState is everything needed to rebuild an agent exactly, as written to checkpoints
+type AgentState struct {
+	ID                 uuid.UUID
+	Attachment         Attachment
+	PTW                PTSParams
+	Telomere           TelomereState
+	Position           PositionVector
+	Speed              int
+	Energy             float64
+	ClusterID          int
+	Heroism            int
+	EliminationHistory EliminationHistoryState
+	Lineage            Lineage
+	Network            map[uuid.UUID]float32
+	NetworkLength      int
+	PTSStats           PTSStatsState
+	Worldview          WorldviewState
+	Ysterofimia        Ysterofimia
+	IsAlive            bool
+}
+
+type TelomereState struct {
+	Age              int
+	Alpha            float64
+	Beta             float64
+	GenerationLength int
+}
+
+func (t *Telomere) State() TelomereState {
+	return TelomereState{Age: t.age, Alpha: t.alpha, Beta: t.beta, GenerationLength: t.generationLength}
+}
+
+func RestoreTelomere(state TelomereState) *Telomere {
+	return &Telomere{state.Age, state.Alpha, state.Beta, state.GenerationLength}
+}
+
+type WorldviewState struct {
+	Hash             byte
+	History          []byte
+	DunbarProportion float64
+}
+
+func (wv *Worldview) State() WorldviewState {
+	return WorldviewState{
+		Hash:             wv.worldviewHash,
+		History:          append([]byte{}, wv.worldviewHistory...),
+		DunbarProportion: wv.dunbarProportion,
+	}
+}
+
+func RestoreWorldview(state WorldviewState) *Worldview {
+	return &Worldview{
+		worldviewHash:    state.Hash,
+		worldviewHistory: append(make([]byte, 0, len(state.History)), state.History...),
+		dunbarProportion: state.DunbarProportion,
+	}
+}
+
+type PTSStatsState struct {
+	CreatedBy int
+	CreatedTo int
+	SeveredBy int
+	SeveredTo int
+}
+
+func (pts *PTS_Stats) State() PTSStatsState {
+	return PTSStatsState{CreatedBy: pts.createdBy, CreatedTo: pts.createdTo, SeveredBy: pts.severedBy, SeveredTo: pts.severedTo}
+}
+
+func RestorePTSStats(state PTSStatsState) *PTS_Stats {
+	return &PTS_Stats{
+		createdBy: state.CreatedBy,
+		createdTo: state.CreatedTo,
+		severedBy: state.SeveredBy,
+		severedTo: state.SeveredTo,
+	}
+}
+
+type EliminationHistoryState struct {
+	ObservedCluster  int
+	ClusterTolerance int
+	ObservedNetwork  int
+	NetworkTolerance int
+}
+
+func (eh *EliminationHistory) State() EliminationHistoryState {
+	return EliminationHistoryState{
+		ObservedCluster:  eh.observedEliminationsCluster,
+		ClusterTolerance: eh.clusterEliminationTolerance,
+		ObservedNetwork:  eh.observedEliminationsNetwork,
+		NetworkTolerance: eh.networkEliminationTolerance,
+	}
+}
+
+func RestoreEliminationHistory(state EliminationHistoryState) *EliminationHistory {
+	return &EliminationHistory{
+		observedEliminationsCluster: state.ObservedCluster,
+		clusterEliminationTolerance: state.ClusterTolerance,
+		observedEliminationsNetwork: state.ObservedNetwork,
+		networkEliminationTolerance: state.NetworkTolerance,
+	}
+}
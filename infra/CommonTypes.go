@@ -1,11 +1,16 @@
 package infra
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
+	"maps"
 	"math"
 	"math/bits"
-	"math/rand"
+	"math/rand/v2"
+	"slices"
 
+	"github.com/aaashah/TMT_FYP/config"
 	"github.com/google/uuid"
 )
 
@@ -93,6 +98,25 @@ func (t *Telomere) GetProbabilityOfDeath() float64 {
 
 type SocialNetwork map[uuid.UUID]float32
 
+// NewAgentID draws a version 4 UUID from rng, so agent IDs are reproducible from the seed
+func NewAgentID(rng *rand.Rand) uuid.UUID {
+	var id uuid.UUID
+	binary.BigEndian.PutUint64(id[:8], rng.Uint64())
+	binary.BigEndian.PutUint64(id[8:], rng.Uint64())
+	id[6] = (id[6] & 0x0f) | 0x40 // version 4
+	id[8] = (id[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return id
+}
+
+// SortedIDs returns the keys of an ID-keyed map in ascending order.
+// Go randomises map iteration, so anything that draws random numbers or
+// accumulates floats over agents must iterate in this order to be reproducible.
+func SortedIDs[V any](m map[uuid.UUID]V) []uuid.UUID {
+	return slices.SortedFunc(maps.Keys(m), func(a, b uuid.UUID) int {
+		return bytes.Compare(a[:], b[:])
+	})
+}
+
 type ClusterEliminations struct {
 	TotalEliminations []int // eliminations in this cluster per turn
 	ClusterSizes      []int // size of this cluster per turn
@@ -145,6 +169,73 @@ func (y *Ysterofimia) ComputeYsterofimia() float32 {
 	}
 }
 
+// MortalitySalienceScores are the inputs to the mortality salience module
+type MortalitySalienceScores struct {
+	ClusterEliminations float32 // CE
+	NetworkEliminations float32 // NE
+	RelativeAge         float32 // RA
+	MemorialProximity   float32 // MP
+}
+
+func (ms MortalitySalienceScores) Score(w config.MortalitySalienceWeights) float32 {
+	return float32(w.ClusterEliminations)*ms.ClusterEliminations + float32(w.NetworkEliminations)*ms.NetworkEliminations +
+		float32(w.RelativeAge)*ms.RelativeAge + float32(w.MemorialProximity)*ms.MemorialProximity
+}
+
+// WorldviewValidationScores are the inputs to the worldview validation module
+type WorldviewValidationScores struct {
+	ClusterProfile float32 // CPR
+	NetworkProfile float32 // NPR
+	Ysterofimia    float32
+}
+
+func (wv WorldviewValidationScores) Score(w config.WorldviewValidationWeights) float32 {
+	return float32(w.ClusterProfile)*wv.ClusterProfile + float32(w.NetworkProfile)*wv.NetworkProfile + float32(w.Ysterofimia)*wv.Ysterofimia
+}
+
+// RelationshipValidationScores are the inputs to the relationship validation module
+type RelationshipValidationScores struct {
+	Estrangement    float32 // EST
+	ProSocialEsteem float32 // PSE
+	HeroismTendency float32
+}
+
+func (rv RelationshipValidationScores) Score(w config.RelationshipValidationWeights) float32 {
+	return float32(w.Estrangement)*rv.Estrangement + float32(w.ProSocialEsteem)*rv.ProSocialEsteem + float32(w.HeroismTendency)*rv.HeroismTendency
+}
+
+// ASMScores holds every sub-score behind one ASM decision
+type ASMScores struct {
+	MortalitySalience      MortalitySalienceScores
+	WorldviewValidation    WorldviewValidationScores
+	RelationshipValidation RelationshipValidationScores
+}
+
+// ASMOutcome is one ASM decision with everything it changes, so decisions made
+// concurrently can be applied afterwards in a fixed order
+type ASMOutcome struct {
+	Scores         ASMScores
+	ThresholdScore float64 // mean share of tau reached by the module scores, each capped at 1
+	Decision       ASMDecison
+}
+
+// Lineage records where an agent came from. Founders have nil parents,
+// generation 0 and birth iteration -1 (present before the first iteration).
+type Lineage struct {
+	Parent1        uuid.UUID
+	Parent2        uuid.UUID
+	Generation     int
+	BirthIteration int
+}
+
+func FounderLineage() Lineage {
+	return Lineage{Parent1: uuid.Nil, Parent2: uuid.Nil, Generation: 0, BirthIteration: -1}
+}
+
+func (l Lineage) IsFounder() bool {
+	return l.Parent1 == uuid.Nil && l.Parent2 == uuid.Nil
+}
+
 type DeathInfo struct {
 	Agent        IExtendedAgent
 	WasVoluntary bool
@@ -207,11 +298,11 @@ func (wv1 *Worldview) CompareWorldviews(wv2 *Worldview) float64 {
 	return worldviewAlignment
 }
 
-func NewWorldview(hash byte) *Worldview {
+func NewWorldview(hash byte, rng *rand.Rand) *Worldview {
 	return &Worldview{
 		worldviewHash:    hash,
 		worldviewHistory: make([]byte, 0),
-		dunbarProportion: rand.Float64() + 1,
+		dunbarProportion: rng.Float64() + 1,
 	}
 }
 
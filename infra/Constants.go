@@ -8,6 +8,19 @@ const (
 	INACTION
 )
 
+func (d ASMDecison) String() string {
+	switch d {
+	case SELF_SACRIFICE:
+		return "SelfSacrifice"
+	case NOT_SELF_SACRIFICE:
+		return "NotSelfSacrifice"
+	case INACTION:
+		return "Inaction"
+	default:
+		return "Unknown"
+	}
+}
+
 type AttachmentType int
 
 const (
@@ -19,18 +32,17 @@ const (
 
 var AllAttachmentTypes = []AttachmentType{DISMISSIVE, FEARFUL, PREOCCUPIED, SECURE}
 
-const (
-	// ASM weights
-	W1 float32 = 0.25
-	W2 float32 = 0.25
-	W3 float32 = 0.25
-	W4 float32 = 0.25
-
-	W5 float32 = 0.33
-	W6 float32 = 0.33
-	W7 float32 = 0.34
-
-	W8  float32 = 0.33
-	W9  float32 = 0.33
-	W10 float32 = 0.34
-)
+func (at AttachmentType) String() string {
+	switch at {
+	case DISMISSIVE:
+		return "Dismissive"
+	case FEARFUL:
+		return "Fearful"
+	case PREOCCUPIED:
+		return "Preoccupied"
+	case SECURE:
+		return "Secure"
+	default:
+		return "Unknown"
+	}
+}
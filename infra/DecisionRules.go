@@ -0,0 +1,146 @@
+package infra
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+
+	"github.com/aaashah/TMT_FYP/config"
+)
+
+// ModuleScores are the combined scores of the three ASM modules
+type ModuleScores struct {
+	MortalitySalience      float32
+	WorldviewValidation    float32
+	RelationshipValidation float32
+}
+
+// DecisionRule turns an agent's module scores and the threshold tau into an ASM decision.
+// Stochastic rules draw from rng, which is the server's seeded source.
+type DecisionRule interface {
+	Decide(scores ModuleScores, threshold float32, rng *rand.Rand) ASMDecison
+}
+
+// NewDecisionRule builds the rule named by cfg.DecisionRule
+func NewDecisionRule(cfg config.Config) (DecisionRule, error) {
+	weights := cfg.ModuleWeights
+	switch cfg.DecisionRule {
+	case "vote":
+		return MajorityVoteRule{}, nil
+	case "weighted":
+		return WeightedSumRule{Weights: weights}, nil
+	case "logistic":
+		return LogisticRule{Weights: weights, Temperature: cfg.DecisionTemperature}, nil
+	case "softmax":
+		return SoftmaxRule{Weights: weights, Temperature: cfg.DecisionTemperature}, nil
+	case "margin":
+		return MarginRule{Weights: weights, Margin: cfg.DecisionMargin}, nil
+	default:
+		return nil, fmt.Errorf("unknown decision rule %q", cfg.DecisionRule)
+	}
+}
+
+// MajorityVoteRule has each module vote for or against self-sacrifice depending on whether it
+// clears tau. With three votes there is never a tie, so it never returns INACTION.
+type MajorityVoteRule struct{}
+
+func (MajorityVoteRule) Decide(scores ModuleScores, threshold float32, rng *rand.Rand) ASMDecison {
+	sum := 0
+	for _, score := range []float32{scores.MortalitySalience, scores.WorldviewValidation, scores.RelationshipValidation} {
+		if score > threshold {
+			sum += 1
+		} else {
+			sum -= 1
+		}
+	}
+
+	if sum > 0 {
+		return SELF_SACRIFICE
+	} else if sum < 0 {
+		return NOT_SELF_SACRIFICE
+	}
+	return INACTION
+}
+
+// WeightedSumRule volunteers when the weighted sum of the module scores clears tau
+type WeightedSumRule struct {
+	Weights config.ModuleWeights
+}
+
+func (r WeightedSumRule) Decide(scores ModuleScores, threshold float32, rng *rand.Rand) ASMDecison {
+	if combineScores(scores, r.Weights) > float64(threshold) {
+		return SELF_SACRIFICE
+	}
+	return NOT_SELF_SACRIFICE
+}
+
+// LogisticRule volunteers with probability 1 / (1 + exp(-(s - tau) / T)), where s is
+// the weighted sum of the module scores. Low temperatures approach WeightedSumRule.
+type LogisticRule struct {
+	Weights     config.ModuleWeights
+	Temperature float64
+}
+
+func (r LogisticRule) Decide(scores ModuleScores, threshold float32, rng *rand.Rand) ASMDecison {
+	excess := combineScores(scores, r.Weights) - float64(threshold)
+	probability := 1 / (1 + math.Exp(-excess/r.Temperature))
+	if rng.Float64() < probability {
+		return SELF_SACRIFICE
+	}
+	return NOT_SELF_SACRIFICE
+}
+
+// SoftmaxRule chooses between volunteering (utility s - tau), refusing (tau - s) and
+// inaction (0) with softmax probabilities at temperature T, so agents close to tau
+// are the most likely to do nothing.
+type SoftmaxRule struct {
+	Weights     config.ModuleWeights
+	Temperature float64
+}
+
+func (r SoftmaxRule) Decide(scores ModuleScores, threshold float32, rng *rand.Rand) ASMDecison {
+	excess := combineScores(scores, r.Weights) - float64(threshold)
+	decisions := []ASMDecison{SELF_SACRIFICE, NOT_SELF_SACRIFICE, INACTION}
+	utilities := []float64{excess, -excess, 0}
+
+	// subtract the largest utility so exp cannot overflow at low temperatures
+	largest := math.Abs(excess)
+	total := 0.0
+	weights := make([]float64, len(utilities))
+	for i, utility := range utilities {
+		weights[i] = math.Exp((utility - largest) / r.Temperature)
+		total += weights[i]
+	}
+
+	draw := rng.Float64() * total
+	for i, weight := range weights {
+		draw -= weight
+		if draw < 0 {
+			return decisions[i]
+		}
+	}
+	return decisions[len(decisions)-1]
+}
+
+// MarginRule is WeightedSumRule with a band of width 2*Margin around tau in which
+// agents are undecided and return INACTION
+type MarginRule struct {
+	Weights config.ModuleWeights
+	Margin  float64
+}
+
+func (r MarginRule) Decide(scores ModuleScores, threshold float32, rng *rand.Rand) ASMDecison {
+	excess := combineScores(scores, r.Weights) - float64(threshold)
+	if excess > r.Margin {
+		return SELF_SACRIFICE
+	} else if excess < -r.Margin {
+		return NOT_SELF_SACRIFICE
+	}
+	return INACTION
+}
+
+func combineScores(scores ModuleScores, weights config.ModuleWeights) float64 {
+	return weights.MortalitySalience*float64(scores.MortalitySalience) +
+		weights.WorldviewValidation*float64(scores.WorldviewValidation) +
+		weights.RelationshipValidation*float64(scores.RelationshipValidation)
+}
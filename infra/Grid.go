@@ -1,97 +1,334 @@
 package infra
 
 import (
-	"math/rand"
+	"cmp"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
 	"sync"
 )
 
 type Grid struct {
-	Width      int
-	Height     int
-	positions  map[PositionVector]IExtendedAgent
-	Tombstones []PositionVector
-	Temples    []PositionVector
-	mutex      sync.Mutex
+	Width         int
+	Height        int
+	Topology      Topology
+	Neighbourhood Neighbourhood
+	positions     map[PositionVector]IExtendedAgent
+	tombstones    []Memorial
+	temples       []Memorial
+	Aging         MemorialAging
+	Food          *ResourceField // nil unless the run has resources
+	layout        []byte         // memorialLayout, nil until it is next needed
+	agentIndex    *spatialIndex[placedAgent]
+	memorialIndex *spatialIndex[indexedMemorial] // nil until it is next needed
+	clusters      map[int][]IExtendedAgent       // agents by cluster, nil until next needed
+	search        pathSearch
+	mutex         sync.Mutex
+	rng           *rand.Rand
 }
 
-func NewGrid(width, height int) *Grid {
+func NewGrid(width, height int, rng *rand.Rand) *Grid {
 	return &Grid{
-		Width:      width,
-		Height:     height,
-		positions:  make(map[PositionVector]IExtendedAgent),
-		Tombstones: []PositionVector{},
-		Temples:    []PositionVector{},
+		Width:         width,
+		Height:        height,
+		Topology:      BoundedTopology{Width: width, Height: height},
+		Neighbourhood: VonNeumannNeighbourhood{},
+		positions:     make(map[PositionVector]IExtendedAgent),
+		agentIndex:    newSpatialIndex[placedAgent](width, height),
+		tombstones:    []Memorial{},
+		temples:       []Memorial{},
+		rng:           rng,
 	}
 }
 
 // Check if a cell is occupied
 func (g *Grid) IsOccupied(x, y int) bool {
-	pos := PositionVector{X: x, Y: y}
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.isOccupied(PositionVector{X: x, Y: y})
+}
+
+// isOccupied is IsOccupied for callers already holding the grid's mutex
+func (g *Grid) isOccupied(pos PositionVector) bool {
 	if _, exists := g.positions[pos]; exists {
 		return true
 	}
-
-	target := PositionVector{X: x, Y: y}
-	for _, t := range g.Tombstones {
-		if t == target {
-			return true
-		}
+	if pos.X < 0 || pos.X >= g.Width || pos.Y < 0 || pos.Y >= g.Height {
+		return false
 	}
-	for _, temple := range g.Temples {
-		if temple == target {
-			return true
-		}
-	}
-	return false
+	return g.memorialLayoutLocked()[pos.X*g.Height+pos.Y] == memorialCell
 }
 
 // Place a tombstone at an agent's last known position
-func (g *Grid) PlaceTombstone(x, y int) {
+func (g *Grid) PlaceTombstone(x, y, iteration int) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.tombstones = append(g.tombstones, newMemorial(x, y, iteration)) // Mark the position as a tombstone
+	g.memorialsChanged()
+}
+
+func (g *Grid) PlaceTemple(x, y, iteration int) {
 	g.mutex.Lock()
 	defer g.mutex.Unlock()
-	g.Tombstones = append(g.Tombstones, PositionVector{X: x, Y: y}) // Mark the position as a tombstone
+	g.temples = append(g.temples, newMemorial(x, y, iteration)) // Mark the position as a temple
+	g.memorialsChanged()
 }
 
-func (g *Grid) PlaceTemple(x, y int) {
+// ClearTemples removes every temple from the grid and returns how many there were
+func (g *Grid) ClearTemples() int {
 	g.mutex.Lock()
 	defer g.mutex.Unlock()
-	g.Temples = append(g.Temples, PositionVector{X: x, Y: y}) // Mark the position as a temple
+	removed := len(g.temples)
+	g.temples = []Memorial{}
+	g.memorialsChanged()
+	return removed
 }
 
-// Get a valid move for an agent
-func (g *Grid) GetValidMove(x, y int) (int, int) {
+// CanEnter reports whether pos is a free cell of the grid, once wrapped by the topology
+func (g *Grid) CanEnter(pos PositionVector) (PositionVector, bool) {
 	g.mutex.Lock()
 	defer g.mutex.Unlock()
+	return g.canEnter(pos)
+}
+
+func (g *Grid) canEnter(pos PositionVector) (PositionVector, bool) {
+	wrapped, ok := g.topology().Wrap(pos)
+	return wrapped, ok && !g.isOccupied(wrapped)
+}
 
-	moves := [][2]int{{0, 1}, {0, -1}, {1, 0}, {-1, 0}}
-	rand.Shuffle(len(moves), func(i, j int) { moves[i], moves[j] = moves[j], moves[i] })
+// FreeNeighbours lists the free cells one step from pos, in the neighbourhood's order
+func (g *Grid) FreeNeighbours(pos PositionVector) []PositionVector {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	free := make([]PositionVector, 0)
+	for _, offset := range g.neighbourhood().Offsets(pos) {
+		if newPos, ok := g.canEnter(pos.Add(offset)); ok {
+			free = append(free, newPos)
+		}
+	}
+	return free
+}
+
+// RandomStep picks a free neighbouring cell uniformly, and reports false if there is none
+func (g *Grid) RandomStep(pos PositionVector) (PositionVector, bool) {
+	free := g.FreeNeighbours(pos)
+	if len(free) == 0 {
+		return pos, false
+	}
+	return free[g.rng.IntN(len(free))], true
+}
 
-	for _, move := range moves {
-		newX, newY := x+move[0], y+move[1]
-		if newX > 0 && newX <= g.Width && newY > 0 && newY <= g.Height && !g.IsOccupied(newX, newY) {
-			return newX, newY
+// StepToward picks the free neighbouring cell closest to target, breaking ties at
+// random. It reports false when no free neighbour is closer to target than from,
+// so the path is blocked.
+func (g *Grid) StepToward(from, target PositionVector) (PositionVector, bool) {
+	best := make([]PositionVector, 0)
+	bestDist := g.Dist(from, target)
+	for _, pos := range g.FreeNeighbours(from) {
+		dist := g.Dist(pos, target)
+		if dist < bestDist {
+			best, bestDist = []PositionVector{pos}, dist
+		} else if dist == bestDist && len(best) > 0 {
+			best = append(best, pos)
 		}
 	}
-	return x, y // Stay in place if no move is available
+	if len(best) == 0 {
+		return from, false
+	}
+	return best[g.rng.IntN(len(best))], true
+}
+
+// Displacement is the shortest whole-cell displacement from one position to another
+func (g *Grid) Displacement(from, to PositionVector) PositionVector {
+	dx, dy := g.topology().Offset(float64(from.X), float64(from.Y), float64(to.X), float64(to.Y))
+	return PositionVector{X: int(dx), Y: int(dy)}
+}
+
+// Dist is the distance between two positions under the grid's topology
+func (g *Grid) Dist(p1, p2 PositionVector) float64 {
+	return Distance(g.topology(), float64(p1.X), float64(p1.Y), float64(p2.X), float64(p2.Y))
+}
+
+// CentroidDist is the distance from a position to a k-means centroid under the grid's topology
+func (g *Grid) CentroidDist(pos PositionVector, c *Centroid) float64 {
+	return Distance(g.topology(), float64(pos.X), float64(pos.Y), c.X, c.Y)
+}
+
+// neighbourhood falls back to von Neumann for grids built without NewGrid
+func (g *Grid) neighbourhood() Neighbourhood {
+	if g.Neighbourhood == nil {
+		return VonNeumannNeighbourhood{}
+	}
+	return g.Neighbourhood
 }
 
-// Update agent position on the grid
-func (g *Grid) UpdateAgentPosition(agent IExtendedAgent, newPos PositionVector) {
+// topology falls back to a bounded grid for grids built without NewGrid
+func (g *Grid) topology() Topology {
+	if g.Topology == nil {
+		return BoundedTopology{Width: g.Width, Height: g.Height}
+	}
+	return g.Topology
+}
+
+// Update agent position on the grid, reporting whether the agent moved
+func (g *Grid) UpdateAgentPosition(agent IExtendedAgent, newPos PositionVector) bool {
 	g.mutex.Lock()
 	defer g.mutex.Unlock()
 
 	// Ensure the new position is not already occupied OR a tombstone
-	if g.IsOccupied(newPos.X, newPos.Y) {
+	if g.isOccupied(newPos) {
 		//fmt.Printf(" Agent %v tried to move onto an occupied cell (%d, %d). Movement canceled.\n", agent.GetID(), newX, newY)
-		return
+		return false
 	}
 
 	// Remove from old position
-	oldPos := agent.GetPosition()
-	delete(g.positions, oldPos)
+	g.vacate(agent)
 
 	// Update new position
 	g.positions[newPos] = agent
+	g.indexAgent(agent, newPos)
+	return true
+}
+
+// PlaceAgent marks pos as occupied by agent without vacating any other cell.
+// It is used to spawn agents and to rebuild a grid from a checkpoint.
+func (g *Grid) PlaceAgent(agent IExtendedAgent, pos PositionVector) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.positions[pos] = agent
+	g.indexAgent(agent, pos)
+	g.clusters = nil
+}
+
+// RemoveAgent frees the cell a dead agent stood on
+func (g *Grid) RemoveAgent(agent IExtendedAgent) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.vacate(agent)
+	g.clusters = nil
+}
+
+// vacate frees the agent's cell, but only if the agent is the one registered there
+func (g *Grid) vacate(agent IExtendedAgent) {
+	oldPos := agent.GetPosition()
+	if occupant, ok := g.positions[oldPos]; ok && occupant.GetID() == agent.GetID() {
+		delete(g.positions, oldPos)
+		g.unindexAgent(agent, oldPos)
+	}
+}
+
+// FreeCells lists every cell with no agent, tombstone or temple on it, column by column
+func (g *Grid) FreeCells() []PositionVector {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.freeCells()
+}
+
+func (g *Grid) freeCells() []PositionVector {
+	free := make([]PositionVector, 0)
+	for x := range g.Width {
+		for y := range g.Height {
+			if pos := (PositionVector{X: x, Y: y}); !g.isOccupied(pos) {
+				free = append(free, pos)
+			}
+		}
+	}
+	return free
+}
+
+// freeCellDraws is how many cells RandomFreeCell tries at random before it lists
+// every free cell, which only a nearly full grid should need
+const freeCellDraws = 32
+
+// RandomFreeCell draws a free cell uniformly, and reports false if the grid is full
+func (g *Grid) RandomFreeCell() (PositionVector, bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	for range freeCellDraws {
+		pos := PositionVector{X: g.rng.IntN(g.Width), Y: g.rng.IntN(g.Height)}
+		if !g.isOccupied(pos) {
+			return pos, true
+		}
+	}
+	free := g.freeCells()
+	if len(free) == 0 {
+		return PositionVector{}, false
+	}
+	return free[g.rng.IntN(len(free))], true
+}
+
+// FreeCellNear draws a free cell uniformly from those within radius of anchor. If
+// there are none it falls back to the free cell nearest the anchor, and reports false
+// only if the grid is full.
+func (g *Grid) FreeCellNear(anchor Centroid, radius float64) (PositionVector, bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	near := make([]PositionVector, 0)
+	xLo, xHi := cellSpan(anchor.X, radius, g.Width)
+	yLo, yHi := cellSpan(anchor.Y, radius, g.Height)
+	for x := xLo; x <= xHi; x++ {
+		for y := yLo; y <= yHi; y++ {
+			pos, ok := g.topology().Wrap(PositionVector{X: x, Y: y})
+			if ok && !g.isOccupied(pos) && g.CentroidDist(pos, &anchor) <= radius {
+				near = append(near, pos)
+			}
+		}
+	}
+	if len(near) > 0 {
+		// column by column, whichever side of a wrapped edge the cells lie
+		slices.SortFunc(near, func(a, b PositionVector) int {
+			return cmp.Or(cmp.Compare(a.X, b.X), cmp.Compare(a.Y, b.Y))
+		})
+		return near[g.rng.IntN(len(near))], true
+	}
+
+	free := g.freeCells()
+	if len(free) == 0 {
+		return PositionVector{}, false
+	}
+	nearest, nearestDist := free[0], math.Inf(1)
+	for _, pos := range free {
+		if dist := g.CentroidDist(pos, &anchor); dist < nearestDist {
+			nearest, nearestDist = pos, dist
+		}
+	}
+	return nearest, true
+}
+
+// cellSpan is the range of cells along an axis of the given size that lie within
+// radius of centre, before wrapping
+func cellSpan(centre, radius float64, size int) (int, int) {
+	if 2*radius+1 >= float64(size) {
+		return 0, size - 1
+	}
+	return int(math.Ceil(centre - radius)), int(math.Floor(centre + radius))
+}
+
+// CheckConsistency reports the first disagreement between the grid and the agents'
+// own positions: an agent missing from its cell, a cell held by a dead or moved
+// agent, or an agent standing on a tombstone or temple
+func (g *Grid) CheckConsistency(agents []IExtendedAgent) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	memorials := make(map[PositionVector]struct{}, len(g.tombstones)+len(g.temples))
+	for _, memorial := range append(slices.Clone(g.tombstones), g.temples...) {
+		memorials[memorial.PositionVector] = struct{}{}
+	}
+	for _, agent := range agents {
+		pos := agent.GetPosition()
+		occupant, ok := g.positions[pos]
+		if !ok || occupant.GetID() != agent.GetID() {
+			return fmt.Errorf("agent %v is at (%d, %d) but the grid does not have it there", agent.GetID(), pos.X, pos.Y)
+		}
+		if _, onMemorial := memorials[pos]; onMemorial {
+			return fmt.Errorf("agent %v is standing on a memorial at (%d, %d)", agent.GetID(), pos.X, pos.Y)
+		}
+	}
+	if len(g.positions) != len(agents) {
+		return fmt.Errorf("grid holds %d agents but %d are alive", len(g.positions), len(agents))
+	}
+	return nil
 }
 
 func (g *Grid) GetAllOccupiedAgentPositions() map[PositionVector]IExtendedAgent {
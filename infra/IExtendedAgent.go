@@ -1,6 +1,8 @@
 package infra
 
 import (
+	"math/rand/v2"
+
 	"github.com/MattSScott/basePlatformSOMAS/v2/pkg/agent"
 	"github.com/aaashah/TMT_FYP/gameRecorder"
 
@@ -17,6 +19,9 @@ type IExtendedAgent interface {
 	GetAge() int
 	GetPosition() PositionVector
 	SetPosition(PositionVector)
+	GetSpeed() int
+	GetEnergy() float64
+	AddEnergy(delta float64)
 	GetWorldview() *Worldview
 	UpdateWorldview(float64, int)
 	GetYsterofimia() *Ysterofimia
@@ -26,6 +31,8 @@ type IExtendedAgent interface {
 	GetTargetPosition() (PositionVector, bool)
 	GetClusterID() int
 	GetASMDecision(grid *Grid) ASMDecison
+	EvaluateASMDecision(grid *Grid, rng *rand.Rand) ASMOutcome
+	ApplyASMOutcome(outcome ASMOutcome)
 	GetPTSParams() PTSParams
 	IncrementClusterEliminations(n int)
 	IncrementNetworkEliminations(n int)
@@ -34,7 +41,10 @@ type IExtendedAgent interface {
 
 	IncrementAge()
 	SetClusterID(id int)
+	GetLineage() Lineage
+	SetLineage(Lineage)
 	MarkAsDead()
+	GetState() AgentState
 
 	// Social network functions
 	AddToSocialNetwork(uuid.UUID, float32)
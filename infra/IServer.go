@@ -1,7 +1,10 @@
 package infra
 
 import (
+	"math/rand/v2"
+
 	"github.com/MattSScott/basePlatformSOMAS/v2/pkg/agent"
+	"github.com/aaashah/TMT_FYP/config"
 	"github.com/google/uuid"
 )
 
@@ -11,7 +14,15 @@ type IServer interface {
 	GetAgentByID(agentID uuid.UUID) (IExtendedAgent, bool)
 	GetAgentMap() map[uuid.UUID]IExtendedAgent
 	SubmitDecisionThreshold(uuid.UUID, float64)
+	SubmitASMDecision(uuid.UUID, ASMScores, ASMDecison)
 	GetASMThreshold() float32
+	GetASMWeights(AttachmentType) config.ASMWeights
+	GetSpeed(AttachmentType) int
+	GetDecisionRule() DecisionRule
 	GetInitNumberAgents() int
+	GetInitialEnergy() float64
+	GetProximityRadius() float64
+	GetGrid() *Grid
 	GetGridDims() (int, int)
+	GetRNG() *rand.Rand
 }
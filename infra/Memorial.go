@@ -0,0 +1,89 @@
+package infra
+
+import (
+	"math"
+	"slices"
+)
+
+// Memorial is a tombstone or temple, with the iteration it was raised in and its
+// salience: how strongly it still reminds agents of death, from 1 when new
+// towards 0
+type Memorial struct {
+	PositionVector
+	Iteration int
+	Salience  float64
+}
+
+func newMemorial(x, y, iteration int) Memorial {
+	return Memorial{PositionVector: PositionVector{X: x, Y: y}, Iteration: iteration, Salience: 1}
+}
+
+func (m Memorial) Age(iteration int) int {
+	return iteration - m.Iteration
+}
+
+// MemorialAging decides how memorials fade. The zero value keeps them forever at
+// full salience.
+type MemorialAging struct {
+	Decay            float64 // fraction of its salience a memorial loses each iteration
+	Lifetime         int     // iterations a memorial stands before it is removed, 0 for ever
+	PermanentTemples bool    // temples neither fade nor get removed
+}
+
+// AgeMemorials sets every memorial's salience for its age at iteration, removes
+// those older than their lifetime and returns how many it removed
+func (g *Grid) AgeMemorials(iteration int) int {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	before := len(g.tombstones) + len(g.temples)
+	g.tombstones = g.Aging.age(g.tombstones, iteration)
+	if !g.Aging.PermanentTemples {
+		g.temples = g.Aging.age(g.temples, iteration)
+	}
+	g.memorialsChanged()
+	return before - len(g.tombstones) - len(g.temples)
+}
+
+func (a MemorialAging) age(memorials []Memorial, iteration int) []Memorial {
+	kept := memorials[:0]
+	for _, memorial := range memorials {
+		age := memorial.Age(iteration)
+		if a.Lifetime > 0 && age > a.Lifetime {
+			continue
+		}
+		memorial.Salience = math.Pow(1-a.Decay, float64(age))
+		kept = append(kept, memorial)
+	}
+	return kept
+}
+
+// Memorials copies out every tombstone, then every temple
+func (g *Grid) Memorials() []Memorial {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	memorials := make([]Memorial, 0, len(g.tombstones)+len(g.temples))
+	return append(append(memorials, g.tombstones...), g.temples...)
+}
+
+// Tombstones copies out the tombstones in the order they were placed
+func (g *Grid) Tombstones() []Memorial {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return slices.Clone(g.tombstones)
+}
+
+// Temples copies out the temples in the order they were placed
+func (g *Grid) Temples() []Memorial {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return slices.Clone(g.temples)
+}
+
+// RestoreMemorials replaces every tombstone and temple, as when resuming from a checkpoint
+func (g *Grid) RestoreMemorials(tombstones, temples []Memorial) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.tombstones = slices.Clone(tombstones)
+	g.temples = slices.Clone(temples)
+	g.memorialsChanged()
+}
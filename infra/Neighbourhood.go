@@ -0,0 +1,51 @@
+package infra
+
+import "fmt"
+
+// Neighbourhood decides which cells an agent can reach in one step
+type Neighbourhood interface {
+	// Offsets lists the steps available from pos, before the topology wraps them
+	Offsets(pos PositionVector) []PositionVector
+}
+
+// NewNeighbourhood builds the neighbourhood named by the -neighbourhood flag
+func NewNeighbourhood(name string) (Neighbourhood, error) {
+	switch name {
+	case "vonNeumann":
+		return VonNeumannNeighbourhood{}, nil
+	case "moore":
+		return MooreNeighbourhood{}, nil
+	case "hex":
+		return HexNeighbourhood{}, nil
+	default:
+		return nil, fmt.Errorf("unknown neighbourhood %q", name)
+	}
+}
+
+// VonNeumannNeighbourhood steps along one axis at a time
+type VonNeumannNeighbourhood struct{}
+
+func (VonNeumannNeighbourhood) Offsets(pos PositionVector) []PositionVector {
+	return []PositionVector{{X: 0, Y: 1}, {X: 0, Y: -1}, {X: 1, Y: 0}, {X: -1, Y: 0}}
+}
+
+// MooreNeighbourhood also steps diagonally
+type MooreNeighbourhood struct{}
+
+func (MooreNeighbourhood) Offsets(pos PositionVector) []PositionVector {
+	return []PositionVector{
+		{X: 0, Y: 1}, {X: 0, Y: -1}, {X: 1, Y: 0}, {X: -1, Y: 0},
+		{X: 1, Y: 1}, {X: 1, Y: -1}, {X: -1, Y: 1}, {X: -1, Y: -1},
+	}
+}
+
+// HexNeighbourhood treats the grid as hexagons with every odd row shifted half a
+// cell to the right, so each cell has six neighbours
+type HexNeighbourhood struct{}
+
+func (HexNeighbourhood) Offsets(pos PositionVector) []PositionVector {
+	if pos.Y%2 == 0 {
+		return []PositionVector{{X: -1, Y: 0}, {X: 1, Y: 0}, {X: -1, Y: -1}, {X: 0, Y: -1}, {X: -1, Y: 1}, {X: 0, Y: 1}}
+	}
+	return []PositionVector{{X: -1, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: -1}, {X: 1, Y: -1}, {X: 0, Y: 1}, {X: 1, Y: 1}}
+}
@@ -0,0 +1,232 @@
+package infra
+
+import (
+	"math"
+	"slices"
+	"sync"
+)
+
+// PathOptions tune FindPath
+type PathOptions struct {
+	MemorialCost float64 // added to each step onto a cell next to a tombstone or temple
+	SearchLimit  int     // cells expanded before settling for the closest cell found so far
+}
+
+// FindPath plans the cheapest route from from to target with A*, walking round
+// memorials and ignoring agents. A target on a memorial is swapped for the nearest
+// cell without one, and an unreachable target for the closest cell found. The route
+// leaves out from, so it is empty if from is already that cell.
+func (g *Grid) FindPath(from, target PositionVector, opts PathOptions) []PositionVector {
+	// a target off a bounded grid is headed for from the nearest edge cell
+	if wrapped, ok := g.topology().Wrap(target); ok {
+		target = wrapped
+	} else {
+		target = PositionVector{X: min(max(target.X, 0), g.Width-1), Y: min(max(target.Y, 0), g.Height-1)}
+	}
+	layout := g.memorialLayout()
+	index := func(pos PositionVector) int { return pos.X*g.Height + pos.Y }
+	if layout[index(target)] == memorialCell {
+		target = g.nearestOpenCell(target, layout)
+	}
+	heuristic := func(pos PositionVector) float64 { return g.Dist(pos, target) }
+
+	g.search.mutex.Lock()
+	defer g.search.mutex.Unlock()
+	search := g.search.reset(g.Width * g.Height)
+	open := &search.queue
+	search.open(index(from), 0, from)
+	open.push(pathNode{pos: from, estimate: heuristic(from), remaining: heuristic(from)})
+
+	best, bestRemaining, bestCost := from, heuristic(from), 0.0
+	for expanded := 0; len(open.nodes) > 0 && expanded < opts.SearchLimit; {
+		node := open.pop()
+		current := node.pos
+		if search.isClosed(index(current)) {
+			continue
+		}
+		search.close(index(current))
+		expanded++
+		currentCost := search.cost[index(current)]
+		if node.remaining < bestRemaining || (node.remaining == bestRemaining && currentCost < bestCost) {
+			best, bestRemaining, bestCost = current, node.remaining, currentCost
+		}
+		if current == target {
+			break
+		}
+
+		for _, offset := range g.neighbourhood().Offsets(current) {
+			next, ok := g.topology().Wrap(current.Add(offset))
+			if !ok || layout[index(next)] == memorialCell || search.isClosed(index(next)) {
+				continue
+			}
+			nextCost := currentCost + g.Dist(current, next)
+			if layout[index(next)] == nearMemorialCell {
+				nextCost += opts.MemorialCost
+			}
+			if search.isOpen(index(next)) && search.cost[index(next)] <= nextCost {
+				continue
+			}
+			search.open(index(next), nextCost, current)
+			remaining := heuristic(next)
+			open.push(pathNode{pos: next, estimate: nextCost + remaining, remaining: remaining, order: open.pushed})
+		}
+	}
+
+	path := make([]PositionVector, 0)
+	for pos := best; pos != from; pos = search.cameFrom[index(pos)] {
+		path = append(path, pos)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// nearestOpenCell is the first cell without a memorial found by a breadth-first
+// search out from pos, or pos itself if every cell holds one
+func (g *Grid) nearestOpenCell(pos PositionVector, layout []byte) PositionVector {
+	seen := map[PositionVector]bool{pos: true}
+	for queue := []PositionVector{pos}; len(queue) > 0; queue = queue[1:] {
+		for _, offset := range g.neighbourhood().Offsets(queue[0]) {
+			next, ok := g.topology().Wrap(queue[0].Add(offset))
+			if !ok || seen[next] {
+				continue
+			}
+			if layout[next.X*g.Height+next.Y] != memorialCell {
+				return next
+			}
+			seen[next] = true
+			queue = append(queue, next)
+		}
+	}
+	return pos
+}
+
+const (
+	openCell byte = iota
+	nearMemorialCell
+	memorialCell
+)
+
+// memorialLayout marks each cell, column by column, as holding a tombstone or
+// temple, being one step from one, or neither. Placing or clearing memorials
+// throws it away to be rebuilt.
+func (g *Grid) memorialLayout() []byte {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.memorialLayoutLocked()
+}
+
+func (g *Grid) memorialLayoutLocked() []byte {
+	if g.layout != nil {
+		return g.layout
+	}
+	layout := make([]byte, g.Width*g.Height)
+	memorials := append(slices.Clone(g.tombstones), g.temples...)
+	for _, memorial := range memorials {
+		for _, offset := range g.neighbourhood().Offsets(memorial.PositionVector) {
+			if near, ok := g.topology().Wrap(memorial.Add(offset)); ok {
+				layout[near.X*g.Height+near.Y] = nearMemorialCell
+			}
+		}
+	}
+	for _, memorial := range memorials {
+		layout[memorial.X*g.Height+memorial.Y] = memorialCell
+	}
+	g.layout = layout
+	return layout
+}
+
+// pathSearch is FindPath's working memory, kept between searches so they do not
+// allocate a grid's worth of slices each time. A cell's entries are only valid
+// if its stamp matches the current search.
+type pathSearch struct {
+	mutex    sync.Mutex
+	cost     []float64
+	cameFrom []PositionVector
+	opened   []uint32
+	closed   []uint32
+	stamp    uint32
+	queue    pathQueue
+}
+
+func (s *pathSearch) reset(cells int) *pathSearch {
+	if len(s.cost) != cells {
+		s.cost = make([]float64, cells)
+		s.cameFrom = make([]PositionVector, cells)
+		s.opened = make([]uint32, cells)
+		s.closed = make([]uint32, cells)
+		s.stamp = 0
+	}
+	s.stamp++
+	s.queue.nodes, s.queue.pushed = s.queue.nodes[:0], 0
+	return s
+}
+
+func (s *pathSearch) open(cell int, cost float64, cameFrom PositionVector) {
+	s.cost[cell], s.cameFrom[cell], s.opened[cell] = cost, cameFrom, s.stamp
+}
+
+func (s *pathSearch) isOpen(cell int) bool   { return s.opened[cell] == s.stamp }
+func (s *pathSearch) close(cell int)         { s.closed[cell] = s.stamp }
+func (s *pathSearch) isClosed(cell int) bool { return s.closed[cell] == s.stamp }
+
+type pathNode struct {
+	pos       PositionVector
+	estimate  float64 // cost so far plus the heuristic
+	remaining float64 // the heuristic alone
+	order     int     // breaks ties in the order cells were found
+}
+
+// pathQueue is the A* open set, a binary heap ordered by estimate, then
+// remaining distance, then discovery
+type pathQueue struct {
+	nodes  []pathNode
+	pushed int
+}
+
+func (q *pathQueue) less(i, j int) bool {
+	a, b := &q.nodes[i], &q.nodes[j]
+	if math.Abs(a.estimate-b.estimate) > 1e-9 {
+		return a.estimate < b.estimate
+	}
+	if a.remaining != b.remaining {
+		return a.remaining < b.remaining
+	}
+	return a.order < b.order
+}
+
+func (q *pathQueue) push(node pathNode) {
+	q.nodes = append(q.nodes, node)
+	q.pushed++
+	for i := len(q.nodes) - 1; i > 0; {
+		parent := (i - 1) / 2
+		if !q.less(i, parent) {
+			break
+		}
+		q.nodes[i], q.nodes[parent] = q.nodes[parent], q.nodes[i]
+		i = parent
+	}
+}
+
+func (q *pathQueue) pop() pathNode {
+	top := q.nodes[0]
+	last := len(q.nodes) - 1
+	q.nodes[0] = q.nodes[last]
+	q.nodes = q.nodes[:last]
+	for i := 0; ; {
+		smallest, left, right := i, 2*i+1, 2*i+2
+		if left < last && q.less(left, smallest) {
+			smallest = left
+		}
+		if right < last && q.less(right, smallest) {
+			smallest = right
+		}
+		if smallest == i {
+			break
+		}
+		q.nodes[i], q.nodes[smallest] = q.nodes[smallest], q.nodes[i]
+		i = smallest
+	}
+	return top
+}
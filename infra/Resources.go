@@ -0,0 +1,44 @@
+package infra
+
+// ResourceField is the food on each cell of the grid. Every cell starts full
+// and grows back Regrowth of its capacity each turn.
+type ResourceField struct {
+	Width, Height int
+	Capacity      float64
+	Regrowth      float64   // fraction of Capacity regained per turn
+	Food          []float64 // column by column
+}
+
+func NewResourceField(width, height int, capacity, regrowth float64) *ResourceField {
+	food := make([]float64, width*height)
+	for i := range food {
+		food[i] = capacity
+	}
+	return &ResourceField{Width: width, Height: height, Capacity: capacity, Regrowth: regrowth, Food: food}
+}
+
+func (r *ResourceField) At(pos PositionVector) float64 {
+	return r.Food[pos.X*r.Height+pos.Y]
+}
+
+// Harvest takes up to wanted food from a cell and returns how much was taken
+func (r *ResourceField) Harvest(pos PositionVector, wanted float64) float64 {
+	cell := pos.X*r.Height + pos.Y
+	taken := min(max(wanted, 0), r.Food[cell])
+	r.Food[cell] -= taken
+	return taken
+}
+
+func (r *ResourceField) Regrow() {
+	for i, food := range r.Food {
+		r.Food[i] = min(food+r.Regrowth*r.Capacity, r.Capacity)
+	}
+}
+
+func (r *ResourceField) Total() float64 {
+	total := 0.0
+	for _, food := range r.Food {
+		total += food
+	}
+	return total
+}
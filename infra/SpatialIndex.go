@@ -0,0 +1,249 @@
+package infra
+
+import (
+	"bytes"
+	"cmp"
+	"math"
+	"slices"
+)
+
+// bucketSize is the side, in cells, of the square blocks the spatial index files
+// agents and memorials under
+const bucketSize = 8
+
+// spatialIndex files entries under the bucketSize × bucketSize block of cells they
+// stand in, so a query only looks at the blocks that could hold an answer
+type spatialIndex[T any] struct {
+	rows    int
+	buckets [][]T
+}
+
+func newSpatialIndex[T any](width, height int) *spatialIndex[T] {
+	columns, rows := (width+bucketSize-1)/bucketSize, (height+bucketSize-1)/bucketSize
+	return &spatialIndex[T]{rows: rows, buckets: make([][]T, columns*rows)}
+}
+
+func (s *spatialIndex[T]) bucket(pos PositionVector) int {
+	return pos.X/bucketSize*s.rows + pos.Y/bucketSize
+}
+
+func (s *spatialIndex[T]) add(pos PositionVector, entry T) {
+	bucket := s.bucket(pos)
+	s.buckets[bucket] = append(s.buckets[bucket], entry)
+}
+
+// placedAgent is an agent filed under the cell the grid has it on
+type placedAgent struct {
+	agent IExtendedAgent
+	pos   PositionVector
+}
+
+func (g *Grid) indexAgent(agent IExtendedAgent, pos PositionVector) {
+	g.agentIndex.add(pos, placedAgent{agent: agent, pos: pos})
+}
+
+func (g *Grid) unindexAgent(agent IExtendedAgent, pos PositionVector) {
+	bucket := g.agentIndex.bucket(pos)
+	g.agentIndex.buckets[bucket] = slices.DeleteFunc(g.agentIndex.buckets[bucket], func(placed placedAgent) bool {
+		return placed.agent.GetID() == agent.GetID()
+	})
+}
+
+// NearestAgents finds the k agents closest to pos for which keep is true, nearest
+// first with ties broken by ID. It searches outwards from pos a ring of buckets at
+// a time and stops once nothing further out could be closer.
+func (g *Grid) NearestAgents(pos PositionVector, k int, keep func(IExtendedAgent) bool) []IExtendedAgent {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	type candidate struct {
+		agent IExtendedAgent
+		dist  float64
+	}
+	candidates := make([]candidate, 0)
+	searched := make([]bool, len(g.agentIndex.buckets))
+	for reach := 0; ; reach += bucketSize {
+		for _, bucket := range g.bucketsNear(pos, reach) {
+			if searched[bucket] {
+				continue
+			}
+			searched[bucket] = true
+			for _, placed := range g.agentIndex.buckets[bucket] {
+				if keep(placed.agent) {
+					candidates = append(candidates, candidate{agent: placed.agent, dist: g.Dist(pos, placed.pos)})
+				}
+			}
+		}
+		slices.SortFunc(candidates, func(a, b candidate) int {
+			if a.dist != b.dist {
+				return cmp.Compare(a.dist, b.dist)
+			}
+			return compareAgentIDs(a.agent, b.agent)
+		})
+		// every cell left unsearched is more than reach away
+		if (len(candidates) >= k && candidates[k-1].dist <= float64(reach)) || reach >= max(g.Width, g.Height) {
+			break
+		}
+	}
+	nearest := make([]IExtendedAgent, 0, k)
+	for _, candidate := range candidates[:min(k, len(candidates))] {
+		nearest = append(nearest, candidate.agent)
+	}
+	return nearest
+}
+
+// NearestAgent is the closest agent to pos for which keep is true, and reports
+// false if there is none
+func (g *Grid) NearestAgent(pos PositionVector, keep func(IExtendedAgent) bool) (IExtendedAgent, bool) {
+	nearest := g.NearestAgents(pos, 1, keep)
+	if len(nearest) == 0 {
+		return nil, false
+	}
+	return nearest[0], true
+}
+
+// AgentsWithin lists, by ID, the agents no further than radius from pos for which
+// keep is true
+func (g *Grid) AgentsWithin(pos PositionVector, radius float64, keep func(IExtendedAgent) bool) []IExtendedAgent {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	within := make([]IExtendedAgent, 0)
+	for _, bucket := range g.bucketsNear(pos, g.reach(radius)) {
+		for _, placed := range g.agentIndex.buckets[bucket] {
+			if keep(placed.agent) && g.Dist(pos, placed.pos) <= radius {
+				within = append(within, placed.agent)
+			}
+		}
+	}
+	slices.SortFunc(within, compareAgentIDs)
+	return within
+}
+
+// MemorialsWithin lists the memorials no further than radius from pos, tombstones
+// then temples, each in the order they were raised
+func (g *Grid) MemorialsWithin(pos PositionVector, radius float64) []Memorial {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	index := g.memorialIndexLocked()
+	found := make([]indexedMemorial, 0)
+	for _, bucket := range g.bucketsNear(pos, g.reach(radius)) {
+		for _, entry := range index.buckets[bucket] {
+			if g.Dist(pos, entry.PositionVector) <= radius {
+				found = append(found, entry)
+			}
+		}
+	}
+	slices.SortFunc(found, func(a, b indexedMemorial) int { return a.order - b.order })
+	within := make([]Memorial, len(found))
+	for i, entry := range found {
+		within[i] = entry.Memorial
+	}
+	return within
+}
+
+// ClusterMembers lists, by ID, the agents on the grid in the given cluster
+func (g *Grid) ClusterMembers(clusterID int) []IExtendedAgent {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if g.clusters == nil {
+		g.clusters = make(map[int][]IExtendedAgent)
+		for _, agent := range g.positions {
+			g.clusters[agent.GetClusterID()] = append(g.clusters[agent.GetClusterID()], agent)
+		}
+		for _, members := range g.clusters {
+			slices.SortFunc(members, compareAgentIDs)
+		}
+	}
+	return slices.Clone(g.clusters[clusterID])
+}
+
+// ClustersChanged throws away the index of cluster members, as when an agent
+// moves to another cluster
+func (g *Grid) ClustersChanged() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.clusters = nil
+}
+
+// indexedMemorial is a memorial with its place in the list of tombstones then temples
+type indexedMemorial struct {
+	Memorial
+	order int
+}
+
+// memorialIndexLocked files every memorial under its bucket. Placing, ageing or
+// clearing memorials throws the index away to be rebuilt.
+func (g *Grid) memorialIndexLocked() *spatialIndex[indexedMemorial] {
+	if g.memorialIndex == nil {
+		g.memorialIndex = newSpatialIndex[indexedMemorial](g.Width, g.Height)
+		for i, memorial := range append(slices.Clone(g.tombstones), g.temples...) {
+			g.memorialIndex.add(memorial.PositionVector, indexedMemorial{Memorial: memorial, order: i})
+		}
+	}
+	return g.memorialIndex
+}
+
+// memorialsChanged throws away everything cached about where memorials stand
+func (g *Grid) memorialsChanged() {
+	g.layout = nil
+	g.memorialIndex = nil
+}
+
+// reach is how many cells along each axis a search must cover to find everything
+// within radius
+func (g *Grid) reach(radius float64) int {
+	whole := max(g.Width, g.Height)
+	if radius >= float64(whole) {
+		return whole
+	}
+	return int(math.Ceil(radius))
+}
+
+// bucketsNear lists the buckets holding the cells within reach of pos along both
+// axes, wrapping round a toroidal grid
+func (g *Grid) bucketsNear(pos PositionVector, reach int) []int {
+	_, wraps := g.topology().Wrap(PositionVector{X: -1, Y: -1})
+	columns := axisBuckets(pos.X, reach, g.Width, wraps)
+	rows := axisBuckets(pos.Y, reach, g.Height, wraps)
+	rowCount := (g.Height + bucketSize - 1) / bucketSize
+	buckets := make([]int, 0, len(columns)*len(rows))
+	for _, column := range columns {
+		for _, row := range rows {
+			buckets = append(buckets, column*rowCount+row)
+		}
+	}
+	return buckets
+}
+
+// axisBuckets lists in order the buckets along one axis that hold a cell within
+// reach of centre
+func axisBuckets(centre, reach, size int, wraps bool) []int {
+	lo, hi := centre-reach, centre+reach
+	var segments [][2]int
+	switch {
+	case hi-lo+1 >= size:
+		segments = [][2]int{{0, size - 1}}
+	case !wraps:
+		segments = [][2]int{{max(lo, 0), min(hi, size-1)}}
+	case lo < 0:
+		segments = [][2]int{{0, hi}, {lo + size, size - 1}}
+	case hi >= size:
+		segments = [][2]int{{0, hi - size}, {lo, size - 1}}
+	default:
+		segments = [][2]int{{lo, hi}}
+	}
+	buckets := make([]int, 0)
+	for _, segment := range segments {
+		for bucket := segment[0] / bucketSize; bucket <= segment[1]/bucketSize; bucket++ {
+			if len(buckets) == 0 || buckets[len(buckets)-1] < bucket {
+				buckets = append(buckets, bucket)
+			}
+		}
+	}
+	return buckets
+}
+
+// compareAgentIDs orders agents the same way as SortedIDs
+func compareAgentIDs(a, b IExtendedAgent) int {
+	aID, bID := a.GetID(), b.GetID()
+	return bytes.Compare(aID[:], bID[:])
+}
@@ -0,0 +1,108 @@
+package infra
+
+import (
+	"fmt"
+	"math"
+)
+
+// Topology decides which cells exist and how far apart two points are. Cells
+// run from 0 to Width-1 and 0 to Height-1 in every topology.
+type Topology interface {
+	// Wrap maps a position onto the grid, and reports whether it is a cell of the grid
+	Wrap(pos PositionVector) (PositionVector, bool)
+	// Offset is the shortest displacement from one point to another
+	Offset(fromX, fromY, toX, toY float64) (float64, float64)
+	// Mean is the centre of a non-empty set of positions
+	Mean(points []PositionVector) Centroid
+}
+
+// NewTopology builds the topology named by the -topology flag
+func NewTopology(name string, width, height int) (Topology, error) {
+	switch name {
+	case "bounded":
+		return BoundedTopology{Width: width, Height: height}, nil
+	case "toroidal":
+		return ToroidalTopology{Width: width, Height: height}, nil
+	default:
+		return nil, fmt.Errorf("unknown topology %q", name)
+	}
+}
+
+// BoundedTopology is a grid with walls: moves off the edge are not allowed
+type BoundedTopology struct {
+	Width, Height int
+}
+
+func (b BoundedTopology) Wrap(pos PositionVector) (PositionVector, bool) {
+	inside := pos.X >= 0 && pos.X < b.Width && pos.Y >= 0 && pos.Y < b.Height
+	return pos, inside
+}
+
+func (b BoundedTopology) Offset(fromX, fromY, toX, toY float64) (float64, float64) {
+	return toX - fromX, toY - fromY
+}
+
+func (b BoundedTopology) Mean(points []PositionVector) Centroid {
+	sumX, sumY := 0, 0
+	for _, point := range points {
+		sumX += point.X
+		sumY += point.Y
+	}
+	return Centroid{X: float64(sumX) / float64(len(points)), Y: float64(sumY) / float64(len(points))}
+}
+
+// ToroidalTopology wraps each edge round to the opposite one
+type ToroidalTopology struct {
+	Width, Height int
+}
+
+func (t ToroidalTopology) Wrap(pos PositionVector) (PositionVector, bool) {
+	return PositionVector{X: wrapInt(pos.X, t.Width), Y: wrapInt(pos.Y, t.Height)}, true
+}
+
+func (t ToroidalTopology) Offset(fromX, fromY, toX, toY float64) (float64, float64) {
+	return wrapOffset(toX-fromX, float64(t.Width)), wrapOffset(toY-fromY, float64(t.Height))
+}
+
+// Mean takes the circular mean along each axis, so a cluster straddling an edge
+// is centred on the edge rather than in the middle of the grid
+func (t ToroidalTopology) Mean(points []PositionVector) Centroid {
+	xs := make([]float64, len(points))
+	ys := make([]float64, len(points))
+	for i, point := range points {
+		xs[i] = float64(point.X)
+		ys[i] = float64(point.Y)
+	}
+	return Centroid{X: circularMean(xs, float64(t.Width)), Y: circularMean(ys, float64(t.Height))}
+}
+
+// Distance is the length of the shortest displacement between two points
+func Distance(t Topology, fromX, fromY, toX, toY float64) float64 {
+	dx, dy := t.Offset(fromX, fromY, toX, toY)
+	return math.Sqrt((dx * dx) + (dy * dy))
+}
+
+func wrapInt(value, size int) int {
+	return ((value % size) + size) % size
+}
+
+// wrapOffset brings a displacement into [-size/2, size/2)
+func wrapOffset(delta, size float64) float64 {
+	return delta - size*math.Floor(delta/size+0.5)
+}
+
+func circularMean(values []float64, size float64) float64 {
+	sumSin, sumCos, sum := 0.0, 0.0, 0.0
+	for _, value := range values {
+		angle := 2 * math.Pi * value / size
+		sumSin += math.Sin(angle)
+		sumCos += math.Cos(angle)
+		sum += value
+	}
+	// points spread evenly round the axis have no circular mean
+	if math.Hypot(sumSin, sumCos) < 1e-9 {
+		return sum / float64(len(values))
+	}
+	angle := math.Atan2(sumSin, sumCos)
+	return math.Mod(angle/(2*math.Pi)*size+size, size)
+}
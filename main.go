@@ -1,36 +1,37 @@
 package main
 
 import (
-	"github.com/aaashah/TMT_FYP/agents"
+	"os"
+
 	"github.com/aaashah/TMT_FYP/config"
-	"github.com/aaashah/TMT_FYP/infra"
 	"github.com/aaashah/TMT_FYP/server"
+	"github.com/aaashah/TMT_FYP/sweep"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "sweep" {
+		sweep.Main(os.Args[2:])
+		return
+	}
+
 	config := config.NewConfig()
+	if config.Resume != "" {
+		checkpoint, err := server.LoadCheckpoint(config.Resume)
+		if err != nil {
+			panic(err)
+		}
+		serv := server.RestoreTMTServer(config, checkpoint)
+		serv.SetGameRunner(serv)
+		serv.Start()
+		return
+	}
+
 	serv := server.CreateTMTServer(config)
 	serv.SetGameRunner(serv)
 
-	agentPopulation := make([]infra.IExtendedAgent, 0)
-
-	totalAgents := float64(config.NumAgents)
-	for range int(totalAgents * config.DismissiveProp) {
-		agentPopulation = append(agentPopulation, agents.CreateDismissiveAgent(serv))
-	}
-	for range int(totalAgents * config.FearfulProp) {
-		agentPopulation = append(agentPopulation, agents.CreateFearfulAgent(serv))
-	}
-	for range int(totalAgents * config.PreoccupiedProp) {
-		agentPopulation = append(agentPopulation, agents.CreatePreoccupiedAgent(serv))
-	}
-	for range int(totalAgents * config.SecureProp) {
-		agentPopulation = append(agentPopulation, agents.CreateSecureAgent(serv))
-	}
-
-	for _, agent := range agentPopulation {
-		serv.AddAgent(agent)
-		if config.Debug {
+	agentPopulation := serv.CreateInitialPopulation()
+	if config.Debug {
+		for _, agent := range agentPopulation {
 			agent.AgentInitialised()
 		}
 	}
@@ -1,12 +1,11 @@
-import json
 import matplotlib.pyplot as plt
+from run_logs import latest_output_path, load_run
 
-log_dir = "JSONlogs/output.json"
 pop_count = []
 turn_numbers = []
 
-with open(log_dir, "r") as file:
-    GAME_DATA = json.load(file)
+with open(latest_output_path(), "r") as file:
+    GAME_DATA = load_run(file)
     turn_number = 0
     for ITER in GAME_DATA["Iterations"]:
         num_alive = ITER["NumberOfAgents"]
@@ -1,14 +1,13 @@
-import json
 import matplotlib.pyplot as plt
 from collections import defaultdict
+from run_logs import latest_output_path, load_run
 
-log_dir = "JSONlogs/output.json"
 attachment_data = {"Secure": [], "Dismissive": [], "Preoccupied": [], "Fearful": []}
 turn_numbers = []
 
 
-with open(log_dir, "r") as file:
-    GAME_DATA = json.load(file)
+with open(latest_output_path(), "r") as file:
+    GAME_DATA = load_run(file)
     turn_number = 0
     for ITER in GAME_DATA["Iterations"]:
         TURN = ITER["Turns"][-1]
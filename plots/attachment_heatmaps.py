@@ -1,12 +1,11 @@
 import os
-import json
 import pandas as pd
 import seaborn as sns
 import matplotlib.pyplot as plt
 import subprocess
 from tqdm import tqdm
+from run_logs import latest_output_path, load_run
 
-log_path = "JSONlogs/output.json"
 attachment_types = ["secure", "dismissive", "preoccupied", "fearful"]
 plot_dir = "figures"
 os.makedirs(plot_dir, exist_ok=True)
@@ -35,8 +34,8 @@ for style in tqdm(attachment_types, desc="Attachment Type Sweep"):
     ], stdout=subprocess.DEVNULL)
 
     # Load JSON output
-    with open(log_path, "r") as file:
-        game_data = json.load(file)
+    with open(latest_output_path(), "r") as file:
+        game_data = load_run(file)
 
     data = []
     for ITER in game_data.get("Iterations", []):
@@ -1,13 +1,12 @@
 import os
-import json
 import pandas as pd
 import seaborn as sns
 import matplotlib.pyplot as plt
 import subprocess
 from tqdm import tqdm
+from run_logs import latest_output_path, load_run
 
 # Constants
-log_path = "JSONlogs/output.json"
 iters = 20
 tau_values = [round(i * 0.1, 1) for i in range(11)]
 attachment_styles = ["Secure", "Dismissive", "Preoccupied", "Fearful"]
@@ -30,8 +29,8 @@ for tau in tqdm(tau_values, desc="Tau sweep"):
         ], stdout=subprocess.DEVNULL)
 
         # Load JSON output
-        with open(log_path, "r") as file:
-            game_data = json.load(file)
+        with open(latest_output_path(), "r") as file:
+            game_data = load_run(file)
 
         iterations = game_data.get("Iterations", [])
         if not iterations:
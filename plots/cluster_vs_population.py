@@ -1,13 +1,12 @@
 import os
-import json
 import pandas as pd
 import seaborn as sns
 import matplotlib.pyplot as plt
 import subprocess
 from tqdm import tqdm
+from run_logs import latest_output_path, load_run
 
 # Constants
-log_path = "JSONlogs/output.json"
 iters = 20
 kappa_values = list(range(1, 11))
 attachment_styles = ["Secure", "Dismissive", "Preoccupied", "Fearful"]
@@ -30,8 +29,8 @@ for kappa in tqdm(kappa_values, desc="Kappa sweep"):
         ], stdout=subprocess.DEVNULL)
 
         # Load JSON output
-        with open(log_path, "r") as file:
-            game_data = json.load(file)
+        with open(latest_output_path(), "r") as file:
+            game_data = load_run(file)
 
         config = game_data.get("Config", {})
         init_agents = config.get("NumAgents", 40)
@@ -1,12 +1,11 @@
-import json
 import pandas as pd
 import seaborn as sns
 import matplotlib.pyplot as plt
 import subprocess
 from tqdm import tqdm
+from run_logs import latest_output_path, load_run
 
 # Constants
-log_path = "JSONlogs/output.json"
 iters = 20
 kappa_values = list(range(1, 11))  # κ from 1 to 10
 results = []
@@ -24,8 +23,8 @@ for kappa in tqdm(kappa_values, desc="Kappa sweep"):
             f"-kappa={kappa}"
         ], stdout=subprocess.DEVNULL)
 
-        with open(log_path, "r") as file:
-            game_data = json.load(file)
+        with open(latest_output_path(), "r") as file:
+            game_data = load_run(file)
 
         # Traverse all iterations and turns
         for iteration in game_data.get("Iterations", []):
@@ -1,9 +1,9 @@
-import json
 import matplotlib.pyplot as plt
 import subprocess
 import numpy as np
 from collections import defaultdict, Counter
 import pandas as pd
+from run_logs import latest_output_path, load_run
 
 
 def classify(value):
@@ -15,7 +15,6 @@ def classify(value):
         return "Expanding"
 
 
-log_dir = "JSONlogs/output.json"
 iters = 30
 attach_types = ["Dismissive", "Fearful", "Preoccupied", "Secure"]
 props = np.identity(4)
@@ -40,8 +39,8 @@ for row, type in zip(props, attach_types):
             ]
         )
 
-        with open(log_dir, "r") as file:
-            GAME_DATA = json.load(file)
+        with open(latest_output_path(), "r") as file:
+            GAME_DATA = load_run(file)
             CONFIG = GAME_DATA["Config"]
             init_agents = CONFIG["NumAgents"]
             FINAL_ITER = GAME_DATA["Iterations"][-1]
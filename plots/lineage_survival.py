@@ -0,0 +1,55 @@
+import csv
+import os
+
+import matplotlib.pyplot as plt
+from run_logs import latest_run_dir
+
+with open(os.path.join(latest_run_dir(), "lineage_agents.csv"), "r") as file:
+    AGENTS = list(csv.DictReader(file))
+
+# rows are in birth order, so parents are always seen before their children
+founders_of = {}
+for agent in AGENTS:
+    parents = [p for p in (agent["Parent1"], agent["Parent2"]) if p]
+    if parents:
+        founders_of[agent["ID"]] = set().union(*(founders_of[p] for p in parents))
+    else:
+        founders_of[agent["ID"]] = {agent["ID"]}
+
+founders = [a for a in AGENTS if not a["Parent1"]]
+living = [a for a in AGENTS if a["DeathCause"] == ""]
+
+living_descendants = {f["ID"]: 0 for f in founders}
+for agent in living:
+    for founder_id in founders_of[agent["ID"]]:
+        living_descendants[founder_id] += 1
+
+extinct = sum(1 for count in living_descendants.values() if count == 0)
+fixed = sum(1 for count in living_descendants.values() if living and count == len(living))
+print(f"Founder lineages: {len(founders)}, extinct: {extinct}, fixed: {fixed}")
+
+# does self-sacrifice run in families?
+sacrificed = {a["ID"] for a in AGENTS if a["DeathCause"] == "SelfSacrifice"}
+dead_children = [a for a in AGENTS if a["Parent1"] and a["DeathCause"] != ""]
+for label, from_sacrificer in (("a self-sacrificing", True), ("no self-sacrificing", False)):
+    group = [a for a in dead_children
+             if (a["Parent1"] in sacrificed or a["Parent2"] in sacrificed) == from_sacrificer]
+    if group:
+        rate = sum(1 for a in group if a["ID"] in sacrificed) / len(group)
+        print(f"Children of {label} parent: {len(group)}, self-sacrifice rate {rate:.2f}")
+
+# living descendants of each founder, grouped by the founder's attachment style
+colors = {"Secure": "green", "Dismissive": "blue", "Preoccupied": "orange", "Fearful": "red"}
+ordered = sorted(founders, key=lambda f: (f["AttachmentStyle"], -living_descendants[f["ID"]]))
+
+plt.figure(figsize=(14, 5))
+plt.bar(range(len(ordered)), [living_descendants[f["ID"]] for f in ordered],
+        color=[colors.get(f["AttachmentStyle"], "gray") for f in ordered])
+for style, color in colors.items():
+    plt.bar(0, 0, color=color, label=style)
+plt.xlabel("Founder")
+plt.ylabel("Living Descendants")
+plt.title("Founder Lineage Survival")
+plt.legend()
+plt.tight_layout()
+plt.show()
@@ -1,41 +1,32 @@
-import json
 import pandas as pd
 import seaborn as sns
 import matplotlib.pylab as plt
 import subprocess
-from tqdm import tqdm
 
-log_dir = "JSONlogs/output.json"
+results_path = "sweeps/rho_vs_tau.csv"
 iters = 20
-data = []
 
-for tau in tqdm([0, 0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9, 1.0]):
-    for rho in tqdm([0, 0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9, 1.0]):
-        pop_change = 0
-        for _ in tqdm(range(iters)):
-            subprocess.run(
-                [
-                    "./tmtSimulator",
-                    "-numAgents=40",
-                    "-iters=200",
-                    f"-rho={rho}",
-                    f"-tau={tau}",
-                ]
-            )
-
-            with open(log_dir, "r") as file:
-                GAME_DATA = json.load(file)
-                CONFIG = GAME_DATA["Config"]
-                init_agents = CONFIG["NumAgents"]
-                FINAL_ITER = GAME_DATA["Iterations"][-1]
-                final_agents = FINAL_ITER["NumberOfAgents"]
-                pop_change += final_agents / init_agents
+# run every (rho, tau) cell concurrently in one sweep
+subprocess.run(
+    [
+        "./tmtSimulator",
+        "sweep",
+        "-param=numAgents=40",
+        "-param=iters=200",
+        "-param=rho=0:1:0.1",
+        "-param=tau=0:1:0.1",
+        f"-replicates={iters}",
+        f"-out={results_path}",
+    ],
+    check=True,
+)
 
-        pop_change /= iters
-        data.append((tau, rho, min(pop_change, 3)))
+results = pd.read_csv(results_path)
+results["value"] = results["PopulationRatio"]
+data = results.groupby(["tau", "rho"], as_index=False)["value"].mean()
+data["value"] = data["value"].clip(upper=3)
 
-# Create a DataFrame
-df = pd.DataFrame(data, columns=["tau", "rho", "value"])
+df = data
 # Pivot into 2D form (rows = rho, columns = tau)
 pivot = df.pivot(index="rho", columns="tau", values="value")
 
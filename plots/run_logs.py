@@ -0,0 +1,52 @@
+import json
+import os
+
+LOG_ROOT = "JSONlogs"
+
+
+def latest_run_dir(log_root=LOG_ROOT):
+    """Directory of the most recent run, as recorded in <log_root>/LATEST."""
+    with open(os.path.join(log_root, "LATEST"), "r") as file:
+        return os.path.join(log_root, file.read().strip())
+
+
+def latest_output_path(log_root=LOG_ROOT):
+    return os.path.join(latest_run_dir(log_root), "output.ndjson")
+
+
+def iter_lines(file):
+    """Yield each record of an output.ndjson file as it is read, without loading the whole run."""
+    for line in file:
+        line = line.strip()
+        if line:
+            yield json.loads(line)
+
+
+def load_run(file):
+    """Rebuild the nested game record from an output.ndjson file:
+
+    {"Config": {...},
+     "Iterations": [{"Iteration": i, "Turns": [...], "AgentThresholds": {...}, "NumberOfAgents": n}, ...]}
+
+    Iterations cut short by a crash keep the turns that were written.
+    """
+    game = {"Config": None, "Iterations": []}
+    pending_turns = {}
+    for record in iter_lines(file):
+        line_type = record.pop("Type")
+        if line_type == "Config":
+            game["Config"] = record["Config"]
+        elif line_type == "Turn":
+            iteration = record.pop("Iteration")
+            pending_turns.setdefault(iteration, []).append(record)
+        elif line_type == "Iteration":
+            record["Turns"] = pending_turns.pop(record["Iteration"], [])
+            game["Iterations"].append(record)
+    for iteration, turns in sorted(pending_turns.items()):
+        game["Iterations"].append({
+            "Iteration": iteration,
+            "Turns": turns,
+            "AgentThresholds": {},
+            "NumberOfAgents": len(turns[-1]["Agents"] or []),
+        })
+    return game
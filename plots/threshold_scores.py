@@ -1,13 +1,12 @@
-import json
 import pandas as pd
 import seaborn as sns
 import matplotlib.pyplot as plt
+from run_logs import latest_output_path, load_run
 
-log_dir = "JSONlogs/output.json"
 data = []
 
-with open(log_dir, "r") as file:
-    GAME_DATA = json.load(file)
+with open(latest_output_path(), "r") as file:
+    GAME_DATA = load_run(file)
     turn_number = 0
     for ITER in GAME_DATA["Iterations"]:
         iteration = ITER["Iteration"]
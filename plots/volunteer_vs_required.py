@@ -1,7 +1,6 @@
-import json
 import matplotlib.pyplot as plt
+from run_logs import latest_output_path, load_run
 
-log_dir = "JSONlogs/output.json"
 volunteers = []
 required = []
 actually_eliminated = []
@@ -9,8 +8,8 @@ turn_numbers = []
 rho = None
 tau = None
 
-with open(log_dir, "r") as file:
-    GAME_DATA = json.load(file)
+with open(latest_output_path(), "r") as file:
+    GAME_DATA = load_run(file)
     config = GAME_DATA["Config"]
     rho = config["PopulationRho"]
     tau = config["ASMThreshold"]
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/aaashah/TMT_FYP/config"
+	"github.com/aaashah/TMT_FYP/gameRecorder"
+	"github.com/stretchr/testify/assert"
+)
+
+func checkpointTestConfig(iterations int) config.Config {
+	conf := config.DefaultConfig()
+	conf.NumAgents = 20
+	conf.NumIterations = iterations
+	conf.NumTurns = 5
+	conf.GridWidth = 20
+	conf.GridHeight = 20
+	conf.Seed = 3
+	return conf
+}
+
+func newCheckpointTestServer(conf config.Config) *TMTServer {
+	serv := CreateTMTServer(conf)
+	serv.SetGameRunner(serv)
+	serv.CreateInitialPopulation()
+	return serv
+}
+
+func runAndRecord(t *testing.T, serv *TMTServer) []byte {
+	var output bytes.Buffer
+	recorder := gameRecorder.NewStreamRecorder(&output, gameRecorder.FlushAtEnd, false)
+	serv.AttachRecorder(recorder)
+	serv.Run()
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return output.Bytes()
+}
+
+// linesAfter keeps the log lines of iterations after iter
+func linesAfter(t *testing.T, output []byte, iter int) []string {
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 1024*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var line struct{ Iteration int }
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatal(err)
+		}
+		if line.Iteration > iter {
+			lines = append(lines, scanner.Text())
+		}
+	}
+	return lines
+}
+
+func TestResumedRunMatchesUninterruptedRun(t *testing.T) {
+	const total, stopAfter = 12, 5
+	uninterrupted := newCheckpointTestServer(checkpointTestConfig(total))
+	fullOutput := runAndRecord(t, uninterrupted)
+
+	interrupted := newCheckpointTestServer(checkpointTestConfig(stopAfter + 1))
+	interrupted.Run()
+	data, err := json.Marshal(interrupted.Checkpoint(stopAfter))
+	assert.NoError(t, err)
+	var checkpoint Checkpoint
+	assert.NoError(t, json.Unmarshal(data, &checkpoint))
+
+	resumed := RestoreTMTServer(checkpointTestConfig(total), &checkpoint)
+	resumed.SetGameRunner(resumed)
+	resumedOutput := runAndRecord(t, resumed)
+
+	expected := linesAfter(t, fullOutput, stopAfter)
+	assert.NotEmpty(t, expected)
+	assert.Equal(t, expected, linesAfter(t, resumedOutput, -1), "A resumed run should log exactly what the uninterrupted run logged")
+	assert.Equal(t, uninterrupted.GetRunSummary(), resumed.GetRunSummary())
+	assert.Equal(t, uninterrupted.GetLineageRecords(), resumed.GetLineageRecords())
+}
+
+func TestForkedCheckpointSharesHistory(t *testing.T) {
+	base := newCheckpointTestServer(checkpointTestConfig(4))
+	base.Run()
+	checkpoint := base.Checkpoint(3)
+
+	forkConfig := checkpointTestConfig(8)
+	forkConfig.PopulationRho = 0.5
+	fork := RestoreTMTServer(forkConfig, checkpoint)
+	fork.SetGameRunner(fork)
+	fork.Run()
+
+	summary := fork.GetRunSummary()
+	assert.Equal(t, 8, summary.IterationsRun)
+	assert.Equal(t, 0.5, fork.config.PopulationRho)
+	assert.Equal(t, checkpoint.Summary.InitialPopulation, summary.InitialPopulation, "The fork keeps the history before the checkpoint")
+}
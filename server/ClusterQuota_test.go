@@ -0,0 +1,70 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/aaashah/TMT_FYP/agents"
+	"github.com/aaashah/TMT_FYP/config"
+	"github.com/aaashah/TMT_FYP/infra"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClusterQuotasAndR0(t *testing.T) {
+	conf := config.DefaultConfig()
+	conf.NumAgents = 20
+	conf.ClusterQuotas = true
+	conf.InitialExpectedChildren = 8
+	conf.MaxExpectedChildren = 10
+	conf.SpawnStrategy = "parent"
+	// room round each death place for every child the cluster could have
+	conf.SpawnRadius = 4
+	serv := CreateTMTServer(conf)
+	serv.SetGameRunner(serv)
+	// agents decide in ID order, so even agents volunteer and odd agents refuse
+	serv.decisionRule = &scriptedRule{decisions: []infra.ASMDecison{infra.SELF_SACRIFICE, infra.NOT_SELF_SACRIFICE}}
+	for range conf.NumAgents {
+		agent := agents.CreateSecureAgent(serv)
+		serv.placeAgent(agent)
+		serv.AddAgent(agent)
+	}
+	serv.registerLineage(serv.sortedAgents())
+	// cluster 0 holds the volunteers and cluster 1 the free-riders
+	serv.clusterMap = make(map[int][]uuid.UUID)
+	for i, agent := range serv.sortedAgents() {
+		agent.SetClusterID(i % 2)
+		serv.clusterMap[i%2] = append(serv.clusterMap[i%2], agent.GetID())
+	}
+	serv.updateClusterQuotas()
+
+	report := serv.getSacrificialEliminationReport()
+	// each cluster of ten needs two sacrifices: cluster 0 gives two volunteers, cluster 1 loses four
+	assert.Len(t, report, 6)
+	assert.Equal(t, 4, serv.numRequiredSacrifices)
+	assert.True(t, serv.clusterQuotas[0].metQuota())
+	assert.False(t, serv.clusterQuotas[1].metQuota())
+
+	serv.applyElimination(report)
+	serv.performSacrifices(report, 0)
+	serv.updateClusterR0()
+	assert.InDelta(t, 8.4, serv.clusterQuotas[0].expectedChildren, 1e-9)
+	assert.InDelta(t, 7.6, serv.clusterQuotas[1].expectedChildren, 1e-9)
+	for agentID, agent := range serv.GetAgentMap() {
+		assert.Equal(t, serv.clusterQuotas[agent.GetClusterID()].expectedChildren, serv.inheritedR0[agentID])
+	}
+
+	deathPlaces := make(map[uuid.UUID]infra.PositionVector)
+	for agentID, deathInfo := range report {
+		deathPlaces[agentID] = deathInfo.Agent.GetPosition()
+	}
+	children := serv.placeAgents(serv.generateNewAgents(0))
+	assert.NotEmpty(t, children)
+	for _, child := range children {
+		lineage := child.GetLineage()
+		// only the cluster that met its quota breeds
+		assert.Equal(t, 0, report[lineage.Parent1].Agent.GetClusterID())
+		// children are placed near where their first parent died, now a memorial
+		assert.LessOrEqual(t, serv.grid.Dist(deathPlaces[lineage.Parent1], child.GetPosition()), conf.SpawnRadius)
+		assert.Equal(t, 8.4, serv.inheritedR0[child.GetID()])
+	}
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/aaashah/TMT_FYP/infra"
+	"github.com/stretchr/testify/assert"
+)
+
+// Run these with -race to check the concurrent decision phase for data races
+func TestConcurrentDecisionsAreReproducible(t *testing.T) {
+	runWith := func(workers int) string {
+		conf := seededConfig(7)
+		conf.DecisionRule = "softmax"
+		conf.DecisionWorkers = workers
+		output := recordSimulation(t, conf)
+		// the config line records the number of workers
+		return string(output[bytes.IndexByte(output, '\n'):])
+	}
+	first := runWith(4)
+	assert.Equal(t, first, runWith(4), "Concurrent runs are byte-identical for a given seed")
+	assert.Equal(t, first, runWith(2), "The number of workers makes no difference")
+	assert.Equal(t, first, runWith(1), "Deciding one agent at a time makes no difference")
+}
+
+func TestConcurrentDecisionsSeeTheWorldBeforeAnyoneDecides(t *testing.T) {
+	conf := seededConfig(3)
+	conf.DecisionRule = "softmax"
+	conf.DecisionWorkers = 4
+	serv := CreateTMTServer(conf)
+	serv.CreateInitialPopulation()
+	serv.applyClustering()
+	agents := serv.sortedAgents()
+	// the phase seeds each agent's generator from the server's next draw
+	state, err := serv.rngSource.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := &rand.PCG{}
+	if err := source.UnmarshalBinary(state); err != nil {
+		t.Fatal(err)
+	}
+	seed := rand.New(source).Uint64()
+	expected := make([]infra.ASMOutcome, len(agents))
+	for i, agent := range agents {
+		expected[i] = agent.EvaluateASMDecision(serv.grid, decisionRNG(seed, i))
+	}
+
+	decisions := serv.decideAll(agents)
+	for i, agent := range agents {
+		assert.Equal(t, expected[i].Decision, decisions[i])
+		assert.Equal(t, expected[i].ThresholdScore, serv.agentDecisionThresholds[agent.GetID()])
+		assert.Equal(t, expected[i].Decision.String(), serv.agentDecisions[agent.GetID()].ASMDecision)
+		if decisions[i] == infra.SELF_SACRIFICE {
+			assert.Equal(t, 1, agent.GetHeroism(), "Volunteers' heroism is counted once the phase is over")
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/aaashah/TMT_FYP/config"
+	"github.com/aaashah/TMT_FYP/infra"
+	"github.com/stretchr/testify/assert"
+)
+
+// newPolicyTestServer has ten agents who all refuse, so rho of 0.2 leaves a shortfall of two
+func newPolicyTestServer(t *testing.T, policy string, multiplier float64) *TMTServer {
+	serv := newInactionTestServer("refuse", infra.NOT_SELF_SACRIFICE)
+	serv.config.EliminationPolicy = policy
+	serv.config.PunishmentMultiplier = multiplier
+	eliminationPolicy, err := NewEliminationPolicy(serv.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serv.eliminationPolicy = eliminationPolicy
+	return serv
+}
+
+func TestMultiplierPolicy(t *testing.T) {
+	assert.Len(t, newPolicyTestServer(t, "multiplier", 2).getSacrificialEliminationReport(), 4)
+	assert.Len(t, newPolicyTestServer(t, "multiplier", 3).getSacrificialEliminationReport(), 6)
+	assert.Len(t, newPolicyTestServer(t, "none", 2).getSacrificialEliminationReport(), 0)
+}
+
+func TestClusterQuotaPolicy(t *testing.T) {
+	serv := newPolicyTestServer(t, "cluster", 2)
+	for i, agent := range serv.sortedAgents() {
+		// seven agents in cluster 0 and three in cluster 1
+		agent.SetClusterID(min(i/7, 1))
+	}
+
+	report := serv.getSacrificialEliminationReport()
+	perCluster := make(map[int]int)
+	for _, deathInfo := range report {
+		perCluster[deathInfo.Agent.GetClusterID()]++
+	}
+	// cluster 0 needs int(0.2*7) = 1 sacrifice and cluster 1 needs none
+	assert.Equal(t, map[int]int{0: 2}, perCluster)
+	assert.Equal(t, 1, serv.numRequiredSacrifices, "The required count is the sum of the cluster quotas, not of rho over everyone")
+}
+
+func TestCentralityPolicyTargetsMostEsteemed(t *testing.T) {
+	serv := newPolicyTestServer(t, "centrality", 0.5)
+	agents := serv.sortedAgents()
+	target := agents[3]
+	for _, agent := range agents {
+		if agent != target {
+			agent.AddToSocialNetwork(target.GetID(), 0.9)
+		}
+	}
+
+	report := serv.getSacrificialEliminationReport()
+	assert.Len(t, report, 1)
+	assert.Contains(t, report, target.GetID())
+}
+
+func TestIncomingEsteemIgnoresSelfLinks(t *testing.T) {
+	serv := newPolicyTestServer(t, "centrality", 0.5)
+	agents := serv.sortedAgents()
+	for _, agent := range agents {
+		agent.AddToSocialNetwork(agent.GetID(), 0.5)
+	}
+	agents[0].AddToSocialNetwork(agents[3].GetID(), 0.2)
+
+	esteem := serv.incomingEsteem()
+	assert.InDelta(t, 0.2, esteem[agents[3].GetID()], 1e-6)
+	assert.Zero(t, esteem[agents[5].GetID()], "An agent's link to itself is not esteem")
+	report := serv.getSacrificialEliminationReport()
+	assert.Contains(t, report, agents[3].GetID())
+}
+
+func TestAgeLotteryFavoursOldAgents(t *testing.T) {
+	serv := newPolicyTestServer(t, "ageLottery", 0.5)
+	elder := serv.sortedAgents()[5]
+	for range 1000 {
+		elder.IncrementAge()
+	}
+
+	report := serv.getSacrificialEliminationReport()
+	assert.Len(t, report, 1)
+	assert.Contains(t, report, elder.GetID())
+}
+
+func TestUnknownEliminationPolicy(t *testing.T) {
+	conf := config.DefaultConfig()
+	conf.EliminationPolicy = "decimation"
+	_, err := NewEliminationPolicy(conf)
+	assert.Error(t, err)
+	assert.Error(t, conf.Validate())
+}
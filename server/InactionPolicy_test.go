@@ -0,0 +1,87 @@
+package server
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/aaashah/TMT_FYP/agents"
+	"github.com/aaashah/TMT_FYP/config"
+	"github.com/aaashah/TMT_FYP/infra"
+	"github.com/stretchr/testify/assert"
+)
+
+// scriptedRule returns its decisions in turn, cycling back to the start
+type scriptedRule struct {
+	decisions []infra.ASMDecison
+	calls     int
+}
+
+func (r *scriptedRule) Decide(scores infra.ModuleScores, threshold float32, rng *rand.Rand) infra.ASMDecison {
+	decision := r.decisions[r.calls%len(r.decisions)]
+	r.calls++
+	return decision
+}
+
+func newInactionTestServer(policy string, decisions ...infra.ASMDecison) *TMTServer {
+	conf := config.DefaultConfig()
+	conf.NumAgents = 10
+	conf.InactionPolicy = policy
+	serv := CreateTMTServer(conf)
+	serv.SetGameRunner(serv)
+	serv.decisionRule = &scriptedRule{decisions: decisions}
+	for range conf.NumAgents {
+		serv.AddAgent(agents.CreateSecureAgent(serv))
+	}
+	return serv
+}
+
+func TestAbstainersDrawnBeforeRefusers(t *testing.T) {
+	serv := newInactionTestServer("first")
+	abstainers := []infra.IExtendedAgent{agents.CreateSecureAgent(serv), agents.CreateSecureAgent(serv)}
+	refusers := []infra.IExtendedAgent{agents.CreateFearfulAgent(serv), agents.CreateFearfulAgent(serv), agents.CreateFearfulAgent(serv)}
+
+	tiers := serv.nonVolunteerTiers(abstainers, refusers)
+	if assert.Len(t, tiers, 2) {
+		assert.ElementsMatch(t, abstainers, tiers[0], "Abstainers should be drawn first")
+		assert.ElementsMatch(t, refusers, tiers[1])
+	}
+}
+
+func TestAbstentionsCountedSeparately(t *testing.T) {
+	serv := newInactionTestServer("first", infra.INACTION, infra.NOT_SELF_SACRIFICE)
+
+	report := serv.getSacrificialEliminationReport()
+	assert.Equal(t, 0, serv.numVolunteeredAgents)
+	assert.Equal(t, 5, serv.numAbstainedAgents)
+	assert.Equal(t, 5, serv.numRefusedAgents)
+
+	// rho of 0.2 needs 2 sacrifices, so with no volunteers 4 abstainers are drawn before any refuser
+	assert.Len(t, report, 4)
+	// agents decide in ID order, so the abstainers are every other agent
+	for i, agent := range serv.sortedAgents() {
+		if _, eliminated := report[agent.GetID()]; eliminated {
+			assert.True(t, i%2 == 0, "Only abstainers should be eliminated")
+		}
+	}
+}
+
+func TestReconsiderAsksAbstainersAgain(t *testing.T) {
+	// first round: all abstain; second round: alternate volunteering and refusing
+	decisions := make([]infra.ASMDecison, 0, 20)
+	for range 10 {
+		decisions = append(decisions, infra.INACTION)
+	}
+	for range 5 {
+		decisions = append(decisions, infra.SELF_SACRIFICE, infra.NOT_SELF_SACRIFICE)
+	}
+	serv := newInactionTestServer("reconsider", decisions...)
+
+	report := serv.getSacrificialEliminationReport()
+	assert.Equal(t, 5, serv.numVolunteeredAgents)
+	assert.Equal(t, 0, serv.numAbstainedAgents)
+	assert.Equal(t, 5, serv.numRefusedAgents)
+	assert.Len(t, report, 2)
+	for _, deathInfo := range report {
+		assert.True(t, deathInfo.WasVoluntary)
+	}
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/aaashah/TMT_FYP/config"
+	"github.com/aaashah/TMT_FYP/gameRecorder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineageLinksChildrenToParents(t *testing.T) {
+	conf := config.DefaultConfig()
+	conf.NumAgents = 20
+	conf.NumIterations = 15
+	conf.NumTurns = 3
+	conf.GridWidth = 20
+	conf.GridHeight = 20
+
+	serv := CreateTMTServer(conf)
+	serv.SetGameRunner(serv)
+	serv.CreateInitialPopulation()
+	serv.Run()
+
+	records := serv.GetLineageRecords()
+	byID := make(map[string]gameRecorder.LineageRecord, len(records))
+	for _, record := range records {
+		byID[record.ID] = record
+	}
+
+	children := 0
+	for _, record := range records {
+		if record.Parent1 == "" {
+			assert.Equal(t, 0, record.Generation)
+			assert.Equal(t, -1, record.BirthIteration)
+			continue
+		}
+		children++
+		parent1, ok1 := byID[record.Parent1]
+		parent2, ok2 := byID[record.Parent2]
+		if assert.True(t, ok1 && ok2, "Parents should appear earlier in the genealogy") {
+			assert.Equal(t, max(parent1.Generation, parent2.Generation)+1, record.Generation)
+		}
+		assert.GreaterOrEqual(t, record.BirthIteration, 0)
+		if record.DeathCause != "" {
+			assert.Greater(t, record.DeathIteration, record.BirthIteration)
+		}
+	}
+	assert.Greater(t, children, 0, "Expected some agents to be born during the run")
+
+	living := 0
+	for _, record := range records {
+		if record.DeathIteration < 0 {
+			living++
+		}
+	}
+	assert.Equal(t, len(serv.GetAgentMap()), living)
+}
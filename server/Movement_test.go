@@ -0,0 +1,138 @@
+package server
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aaashah/TMT_FYP/agents"
+	"github.com/aaashah/TMT_FYP/config"
+	"github.com/aaashah/TMT_FYP/infra"
+	"github.com/stretchr/testify/assert"
+)
+
+// newMovementTestServer puts a secure agent at (0, 0) with a friend to chase at (9, 0)
+func newMovementTestServer(conf config.Config) (*TMTServer, infra.IExtendedAgent) {
+	conf.GridWidth = 10
+	conf.GridHeight = 10
+	serv := CreateTMTServer(conf)
+	chaser := agents.CreateSecureAgent(serv)
+	friend := agents.CreateSecureAgent(serv)
+	for agent, pos := range map[infra.IExtendedAgent]infra.PositionVector{chaser: {X: 0, Y: 0}, friend: {X: 9, Y: 0}} {
+		agent.SetPosition(pos)
+		serv.grid.PlaceAgent(agent, pos)
+		serv.AddAgent(agent)
+	}
+	chaser.AddToSocialNetwork(friend.GetID(), 1)
+	return serv, chaser
+}
+
+func TestSpeedPerAttachmentStyle(t *testing.T) {
+	conf := config.DefaultConfig()
+	conf.Speeds.Secure = 3
+	serv, chaser := newMovementTestServer(conf)
+	assert.Equal(t, 3, chaser.GetSpeed())
+	assert.Equal(t, 2, agents.CreatePreoccupiedAgent(serv).GetSpeed())
+
+	serv.moveAgent(chaser)
+	assert.Equal(t, infra.PositionVector{X: 3, Y: 0}, chaser.GetPosition())
+	assert.NoError(t, serv.grid.CheckConsistency(serv.sortedAgents()))
+}
+
+func TestBlockedPath(t *testing.T) {
+	for _, neighbourhood := range []string{"vonNeumann", "moore"} {
+		conf := config.DefaultConfig()
+		conf.Neighbourhood = neighbourhood
+		serv, chaser := newMovementTestServer(conf)
+		serv.grid.PlaceTombstone(1, 0, 0)
+
+		serv.moveAgent(chaser)
+		if neighbourhood == "vonNeumann" {
+			assert.Equal(t, infra.PositionVector{X: 0, Y: 1}, chaser.GetPosition(), "Agents plan a route round the tombstone")
+		} else {
+			assert.Equal(t, infra.PositionVector{X: 1, Y: 1}, chaser.GetPosition())
+		}
+	}
+
+	// walled into the corner, the agent waits
+	serv, chaser := newMovementTestServer(config.DefaultConfig())
+	serv.grid.PlaceTombstone(1, 0, 0)
+	serv.grid.PlaceTombstone(0, 1, 0)
+	serv.moveAgent(chaser)
+	assert.Equal(t, infra.PositionVector{X: 0, Y: 0}, chaser.GetPosition())
+}
+
+func TestPathCaching(t *testing.T) {
+	conf := config.DefaultConfig()
+	serv, chaser := newMovementTestServer(conf)
+	serv.moveAgent(chaser)
+	path := serv.paths[chaser.GetID()]
+	assert.Equal(t, chaser.GetPosition(), path.at)
+	// agents are not obstacles, so the route ends on the friend, where the chaser will stop
+	assert.Len(t, path.steps, 8)
+
+	// the cached route is followed while the target stays put
+	serv.moveAgent(chaser)
+	assert.Same(t, path, serv.paths[chaser.GetID()])
+	assert.Equal(t, infra.PositionVector{X: 2, Y: 0}, chaser.GetPosition())
+
+	serv.RunStartOfIteration(1)
+	assert.Empty(t, serv.paths, "Routes are replanned every iteration")
+}
+
+func TestNeighbourhoods(t *testing.T) {
+	grid := infra.NewGrid(10, 10, nil)
+	centre := infra.PositionVector{X: 5, Y: 5}
+	assert.Len(t, grid.FreeNeighbours(centre), 4)
+
+	grid.Neighbourhood = infra.MooreNeighbourhood{}
+	assert.Len(t, grid.FreeNeighbours(centre), 8)
+
+	grid.Neighbourhood = infra.HexNeighbourhood{}
+	assert.ElementsMatch(t, []infra.PositionVector{{X: 4, Y: 5}, {X: 6, Y: 5}, {X: 5, Y: 4}, {X: 6, Y: 4}, {X: 5, Y: 6}, {X: 6, Y: 6}},
+		grid.FreeNeighbours(centre), "Odd rows are shifted right")
+	assert.ElementsMatch(t, []infra.PositionVector{{X: 3, Y: 4}, {X: 5, Y: 4}, {X: 3, Y: 3}, {X: 4, Y: 3}, {X: 3, Y: 5}, {X: 4, Y: 5}},
+		grid.FreeNeighbours(infra.PositionVector{X: 4, Y: 4}))
+}
+
+// BenchmarkMoveAgents times one turn of movement for a crowded 150x150 grid
+func BenchmarkMoveAgents(b *testing.B) {
+	conf := config.DefaultConfig()
+	conf.GridWidth = 150
+	conf.GridHeight = 150
+	conf.NumAgents = 1500
+	serv := CreateTMTServer(conf)
+	serv.CreateInitialPopulation()
+	serv.applyClustering()
+	b.ResetTimer()
+	for range b.N {
+		serv.moveAgents()
+	}
+}
+
+// BenchmarkSacrificeDecisions times every agent's end-of-iteration decision on a
+// crowded 150x150 grid strewn with memorials, at the default proximity radius and
+// counting the whole grid
+func BenchmarkSacrificeDecisions(b *testing.B) {
+	for _, radius := range []float64{config.DefaultConfig().ProximityRadius, 0} {
+		b.Run(fmt.Sprintf("radius=%v", radius), func(b *testing.B) {
+			conf := config.DefaultConfig()
+			conf.GridWidth = 150
+			conf.GridHeight = 150
+			conf.NumAgents = 1500
+			conf.ProximityRadius = radius
+			serv := CreateTMTServer(conf)
+			serv.CreateInitialPopulation()
+			serv.applyClustering()
+			occupied := serv.grid.GetAllOccupiedAgentPositions()
+			for cell := 0; cell < 150*150; cell += 15 {
+				if _, ok := occupied[infra.PositionVector{X: cell / 150, Y: cell % 150}]; !ok {
+					serv.grid.PlaceTombstone(cell/150, cell%150, 0)
+				}
+			}
+			b.ResetTimer()
+			for range b.N {
+				serv.getSacrificialEliminationReport()
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/aaashah/TMT_FYP/config"
+	"github.com/aaashah/TMT_FYP/gameRecorder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIterationLogRecordsASMSubScores(t *testing.T) {
+	output := runSeededSimulation(t, 3)
+
+	weights := config.DefaultConfig().ASMWeights
+	msw, wvw, rvw := weights.MortalitySalience, weights.WorldviewValidation, weights.RelationshipValidation
+	decisionsSeen := 0
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 1024*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var line struct {
+			Type string
+			gameRecorder.IterationJSONRecord
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatal(err)
+		}
+		if line.Type != gameRecorder.IterationLine {
+			continue
+		}
+
+		assert.Equal(t, len(line.Thresholds), len(line.Decisions), "Every decision should record its sub-scores")
+		for _, decision := range line.Decisions {
+			decisionsSeen++
+			ms := decision.MortalitySalience
+			expectedMS := float32(msw.ClusterEliminations)*ms.CE + float32(msw.NetworkEliminations)*ms.NE +
+				float32(msw.RelativeAge)*ms.RA + float32(msw.MemorialProximity)*ms.MP
+			assert.InDelta(t, expectedMS, ms.Score, 1e-6)
+
+			wv := decision.WorldviewValidation
+			expectedWV := float32(wvw.ClusterProfile)*wv.CPR + float32(wvw.NetworkProfile)*wv.NPR + float32(wvw.Ysterofimia)*wv.Ysterofimia
+			assert.InDelta(t, expectedWV, wv.Score, 1e-6)
+
+			rv := decision.RelationshipValidation
+			expectedRV := float32(rvw.Estrangement)*rv.EST + float32(rvw.ProSocialEsteem)*rv.PSE + float32(rvw.HeroismTendency)*rv.HeroismTendency
+			assert.InDelta(t, expectedRV, rv.Score, 1e-6)
+
+			assert.Contains(t, []string{"SelfSacrifice", "NotSelfSacrifice", "Inaction"}, decision.ASMDecision)
+		}
+	}
+	assert.NoError(t, scanner.Err())
+	assert.Greater(t, decisionsSeen, 0)
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/aaashah/TMT_FYP/config"
+	"github.com/aaashah/TMT_FYP/gameRecorder"
+	"github.com/stretchr/testify/assert"
+)
+
+func seededConfig(seed int64) config.Config {
+	conf := config.DefaultConfig()
+	conf.NumAgents = 20
+	conf.NumIterations = 15
+	conf.NumTurns = 5
+	conf.GridWidth = 20
+	conf.GridHeight = 20
+	conf.Seed = seed
+	return conf
+}
+
+func runSeededSimulation(t *testing.T, seed int64) []byte {
+	return recordSimulation(t, seededConfig(seed))
+}
+
+// recordSimulation runs conf to the end and returns everything the recorder wrote
+func recordSimulation(t *testing.T, conf config.Config) []byte {
+	serv := CreateTMTServer(conf)
+	serv.SetGameRunner(serv)
+	serv.CreateInitialPopulation()
+	var output bytes.Buffer
+	recorder := gameRecorder.NewStreamRecorder(&output, gameRecorder.FlushAtEnd, false)
+	serv.AttachRecorder(recorder)
+	serv.Run()
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return output.Bytes()
+}
+
+func TestSameSeedGivesIdenticalOutput(t *testing.T) {
+	first := runSeededSimulation(t, 7)
+	second := runSeededSimulation(t, 7)
+	assert.Equal(t, string(first), string(second), "Same seed and config should give byte-identical logs")
+}
+
+func TestDifferentSeedsGiveDifferentOutput(t *testing.T) {
+	first := runSeededSimulation(t, 7)
+	second := runSeededSimulation(t, 8)
+	assert.NotEqual(t, string(first), string(second), "Different seeds should not give identical logs")
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/aaashah/TMT_FYP/agents"
+	"github.com/aaashah/TMT_FYP/config"
+	"github.com/aaashah/TMT_FYP/gameRecorder"
+	"github.com/aaashah/TMT_FYP/infra"
+	"github.com/stretchr/testify/assert"
+)
+
+// newResourceTestServer puts a lone secure agent at (5, 5) on a 10x10 grid with food
+func newResourceTestServer(conf config.Config) (*TMTServer, infra.IExtendedAgent) {
+	conf.GridWidth = 10
+	conf.GridHeight = 10
+	conf.Resources = true
+	serv := CreateTMTServer(conf)
+	agent := agents.CreateSecureAgent(serv)
+	agent.SetPosition(infra.PositionVector{X: 5, Y: 5})
+	serv.grid.PlaceAgent(agent, agent.GetPosition())
+	serv.AddAgent(agent)
+	serv.registerLineage([]infra.IExtendedAgent{agent})
+	return serv, agent
+}
+
+func TestResourceField(t *testing.T) {
+	field := infra.NewResourceField(2, 2, 1, 0.25)
+	cell := infra.PositionVector{X: 1, Y: 0}
+	assert.Equal(t, 4.0, field.Total())
+	assert.Equal(t, 1.0, field.Harvest(cell, 3), "Agents can't take more than the cell holds")
+	assert.Equal(t, 0.0, field.Harvest(cell, 1))
+
+	field.Regrow()
+	assert.Equal(t, 0.25, field.At(cell))
+	for range 5 {
+		field.Regrow()
+	}
+	assert.Equal(t, 1.0, field.At(cell), "Cells grow back to capacity and no further")
+}
+
+func TestForagingAndFeeding(t *testing.T) {
+	conf := config.DefaultConfig()
+	conf.InitialEnergy = 5
+	serv, agent := newResourceTestServer(conf)
+	assert.Equal(t, 5.0, agent.GetEnergy())
+
+	// only one neighbour still has food, so the agent heads there
+	for _, pos := range serv.grid.FreeNeighbours(agent.GetPosition()) {
+		serv.grid.Food.Harvest(pos, conf.FoodCapacity)
+	}
+	serv.grid.Food.Food[6*serv.grid.Height+5] = conf.FoodCapacity
+	serv.moveAgent(agent)
+	assert.Equal(t, infra.PositionVector{X: 6, Y: 5}, agent.GetPosition())
+	assert.InDelta(t, 5+conf.FoodCapacity-conf.Metabolism, agent.GetEnergy(), 1e-9)
+	assert.Equal(t, 0.0, serv.grid.Food.At(agent.GetPosition()))
+}
+
+func TestStarvation(t *testing.T) {
+	conf := config.DefaultConfig()
+	serv, agent := newResourceTestServer(conf)
+	assert.Empty(t, serv.applyStarvation(0))
+
+	agent.AddEnergy(-conf.InitialEnergy)
+	report := serv.applyStarvation(3)
+	assert.Contains(t, report, agent.GetID())
+	assert.False(t, report[agent.GetID()].WasVoluntary)
+	assert.Empty(t, serv.GetAgentMap())
+	assert.Equal(t, gameRecorder.DeathStarvation, serv.lineage[agent.GetID()].deathCause)
+	assert.Equal(t, []string{agent.GetID().String()}, serv.resourceRecord().Starved)
+}
+
+func TestScarcityRho(t *testing.T) {
+	conf := config.DefaultConfig()
+	conf.ScarcityRho = true
+	serv, _ := newResourceTestServer(conf)
+	serv.applyScheduledEvents(0)
+	assert.Equal(t, 0.0, serv.populationRho, "A full grid feeds one agent easily")
+
+	for i := range serv.grid.Food.Food {
+		serv.grid.Food.Food[i] = 0
+	}
+	serv.applyScheduledEvents(0)
+	assert.Equal(t, 1.0, serv.populationRho, "An empty grid feeds nobody")
+}
@@ -1,7 +1,7 @@
 package server
 
 import (
-	"math/rand"
+	"math/rand/v2"
 	"testing"
 
 	"github.com/aaashah/TMT_FYP/infra"
@@ -9,9 +9,11 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+var testKMeansOptions = kMeansOptions{restarts: 1, maxIterations: 100, tolerance: 1e-4}
+
 func TestRunKMeansBasicTwoClusters(t *testing.T) {
 	// Seed random for deterministic centroid selection
-	rand.Seed(42)
+	rng := rand.New(rand.NewPCG(42, 42))
 
 	// Two points far apart → should be in different clusters if numClusters=2
 	positionMap := make(map[uuid.UUID]infra.PositionVector)
@@ -21,14 +23,14 @@ func TestRunKMeansBasicTwoClusters(t *testing.T) {
 	positionMap[agent1ID] = infra.PositionVector{X: 0, Y: 0}
 	positionMap[agent2ID] = infra.PositionVector{X: 100, Y: 100}
 
-	assignments := runKMeans(positionMap, 2)
+	assignments := runKMeans(positionMap, 2, rng, infra.BoundedTopology{}, testKMeansOptions).assignments
 
 	assert.Equal(t, 2, len(assignments), "Both agents should be assigned to a cluster")
 	assert.NotEqual(t, assignments[agent1ID], assignments[agent2ID], "Agents far apart should be in different clusters")
 }
 
 func TestRunKMeansSingleCluster(t *testing.T) {
-	rand.Seed(42)
+	rng := rand.New(rand.NewPCG(42, 42))
 	positionMap := make(map[uuid.UUID]infra.PositionVector)
 
 	for i := 0; i < 5; i++ {
@@ -36,7 +38,7 @@ func TestRunKMeansSingleCluster(t *testing.T) {
 		positionMap[id] = infra.PositionVector{X: int(i), Y: int(i)}
 	}
 
-	assignments := runKMeans(positionMap, 1)
+	assignments := runKMeans(positionMap, 1, rng, infra.BoundedTopology{}, testKMeansOptions).assignments
 
 	for _, cluster := range assignments {
 		assert.Equal(t, 0, cluster, "All agents should be in cluster 0")
@@ -44,13 +46,13 @@ func TestRunKMeansSingleCluster(t *testing.T) {
 }
 
 func TestRunKMeansMoreClustersThanPoints(t *testing.T) {
-	rand.Seed(42)
+	rng := rand.New(rand.NewPCG(42, 42))
 	positionMap := make(map[uuid.UUID]infra.PositionVector)
 
 	agentID := uuid.New()
 	positionMap[agentID] = infra.PositionVector{X: 10, Y: 10}
 
-	assignments := runKMeans(positionMap, 3)
+	assignments := runKMeans(positionMap, 3, rng, infra.BoundedTopology{}, testKMeansOptions).assignments
 
 	assert.Equal(t, 1, len(assignments), "Only one agent to assign")
 }
@@ -58,6 +60,99 @@ func TestRunKMeansMoreClustersThanPoints(t *testing.T) {
 func TestRunKMeansEmptyInput(t *testing.T) {
 	positionMap := make(map[uuid.UUID]infra.PositionVector)
 
-	assignments := runKMeans(positionMap, 2)
+	assignments := runKMeans(positionMap, 2, rand.New(rand.NewPCG(42, 42)), infra.BoundedTopology{}, testKMeansOptions).assignments
 	assert.Nil(t, assignments, "No input positions → should return nil")
-}
\ No newline at end of file
+}
+
+// scatterGroups places size agents round each centre, one cell apart along a row.
+// IDs are fixed so that a seed always picks the same starting centroids.
+func scatterGroups(centres []infra.PositionVector, size int) map[uuid.UUID]infra.PositionVector {
+	positionMap := make(map[uuid.UUID]infra.PositionVector)
+	for group, centre := range centres {
+		for i := range size {
+			positionMap[uuid.UUID{0: byte(group), 1: byte(i)}] = infra.PositionVector{X: centre.X + i - size/2, Y: centre.Y}
+		}
+	}
+	return positionMap
+}
+
+func TestRunKMeansFindsEverySeparatedGroup(t *testing.T) {
+	centres := []infra.PositionVector{{X: 5, Y: 5}, {X: 5, Y: 45}, {X: 45, Y: 5}, {X: 45, Y: 45}, {X: 25, Y: 25}}
+	positionMap := scatterGroups(centres, 5)
+	// a single k-means++ run can still merge two groups; the best of several should not
+	options := testKMeansOptions
+	options.restarts = 10
+	for seed := range uint64(20) {
+		result := runKMeans(positionMap, len(centres), rand.New(rand.NewPCG(seed, seed)), infra.BoundedTopology{}, options)
+		assert.Equal(t, result, runKMeans(positionMap, len(centres), rand.New(rand.NewPCG(seed, seed)), infra.BoundedTopology{}, options),
+			"The same seed gives the same clustering")
+		assert.Equal(t, []int{5, 5, 5, 5, 5}, result.sizes, "Every group is its own cluster with seed %d", seed)
+		assert.InDelta(t, 5*(4+1+0+1+4), result.inertia, 1e-9)
+		for id, pos := range positionMap {
+			centroid := result.centroids[result.assignments[id]]
+			assert.InDelta(t, float64(pos.Y), centroid.Y, 1e-9)
+		}
+	}
+}
+
+func TestRunKMeansRepairsEmptyClusters(t *testing.T) {
+	positionMap := scatterGroups([]infra.PositionVector{{X: 10, Y: 10}}, 6)
+	for seed := range uint64(20) {
+		result := runKMeans(positionMap, 6, rand.New(rand.NewPCG(seed, seed)), infra.BoundedTopology{}, testKMeansOptions)
+		assert.Equal(t, []int{1, 1, 1, 1, 1, 1}, result.sizes, "No cluster is left empty with seed %d", seed)
+		assert.Zero(t, result.inertia)
+	}
+
+	// with fewer distinct positions than clusters some must stay empty
+	stacked := map[uuid.UUID]infra.PositionVector{uuid.New(): {X: 3, Y: 3}, uuid.New(): {X: 3, Y: 3}, uuid.New(): {X: 7, Y: 3}}
+	result := runKMeans(stacked, 3, rand.New(rand.NewPCG(1, 1)), infra.BoundedTopology{}, testKMeansOptions)
+	assert.ElementsMatch(t, []int{2, 1, 0}, result.sizes)
+}
+
+func TestRunKMeansRestartsKeepLowestInertia(t *testing.T) {
+	rng := rand.New(rand.NewPCG(7, 7))
+	positionMap := make(map[uuid.UUID]infra.PositionVector)
+	for range 200 {
+		positionMap[uuid.New()] = infra.PositionVector{X: rng.IntN(50), Y: rng.IntN(50)}
+	}
+	single := runKMeans(positionMap, 6, rand.New(rand.NewPCG(3, 3)), infra.BoundedTopology{}, testKMeansOptions)
+	options := testKMeansOptions
+	options.restarts = 10
+	restarted := runKMeans(positionMap, 6, rand.New(rand.NewPCG(3, 3)), infra.BoundedTopology{}, options)
+	// the first restart draws the same seeds as the single run
+	assert.LessOrEqual(t, restarted.inertia, single.inertia)
+
+	total := 0.0
+	for id, pos := range positionMap {
+		dist := pos.CentroidDist(&restarted.centroids[restarted.assignments[id]])
+		total += dist * dist
+	}
+	assert.InDelta(t, total, restarted.inertia, 1e-6, "Inertia is measured from the returned centroids")
+}
+
+func TestRunKMeansIterationCap(t *testing.T) {
+	rng := rand.New(rand.NewPCG(7, 7))
+	positionMap := make(map[uuid.UUID]infra.PositionVector)
+	for range 200 {
+		positionMap[uuid.New()] = infra.PositionVector{X: rng.IntN(50), Y: rng.IntN(50)}
+	}
+	options := testKMeansOptions
+	options.maxIterations = 1
+	assert.Equal(t, 1, runKMeans(positionMap, 6, rand.New(rand.NewPCG(3, 3)), infra.BoundedTopology{}, options).iterations)
+
+	options.maxIterations, options.tolerance = 100, 0
+	converged := runKMeans(positionMap, 6, rand.New(rand.NewPCG(3, 3)), infra.BoundedTopology{}, options)
+	assert.Greater(t, converged.iterations, 1)
+	assert.Less(t, converged.iterations, 100)
+}
+
+func TestRunKMeansToroidal(t *testing.T) {
+	positionMap := make(map[uuid.UUID]infra.PositionVector)
+	left, right, middle := uuid.New(), uuid.New(), uuid.New()
+	positionMap[left] = infra.PositionVector{X: 0, Y: 10}
+	positionMap[right] = infra.PositionVector{X: 19, Y: 10}
+	positionMap[middle] = infra.PositionVector{X: 10, Y: 10}
+	result := runKMeans(positionMap, 2, rand.New(rand.NewPCG(1, 1)), infra.ToroidalTopology{Width: 20, Height: 20}, testKMeansOptions)
+	assert.Equal(t, result.assignments[left], result.assignments[right], "Agents either side of the wrap share a cluster")
+	assert.NotEqual(t, result.assignments[left], result.assignments[middle])
+}
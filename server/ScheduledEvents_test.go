@@ -0,0 +1,78 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/aaashah/TMT_FYP/config"
+	"github.com/aaashah/TMT_FYP/gameRecorder"
+	"github.com/aaashah/TMT_FYP/infra"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func newEventTestServer(events ...config.Event) *TMTServer {
+	serv := newInactionTestServer("refuse", infra.NOT_SELF_SACRIFICE)
+	serv.config.Events = events
+	serv.registerLineage(serv.sortedAgents())
+	return serv
+}
+
+func TestTemporaryRhoSpike(t *testing.T) {
+	serv := newEventTestServer(
+		config.Event{Iteration: 2, Type: config.EventRho, Value: 0.5, Duration: 2},
+		config.Event{Iteration: 3, Type: config.EventTau, Value: 0.3},
+	)
+	expectedRho := []float64{0.2, 0.2, 0.5, 0.5, 0.2, 0.2}
+	expectedTau := []float32{0.5, 0.5, 0.5, 0.3, 0.3, 0.3}
+	for iter := range expectedRho {
+		serv.RunStartOfIteration(iter)
+		assert.Equal(t, expectedRho[iter], serv.populationRho, "rho in iteration %d", iter)
+		assert.Equal(t, expectedTau[iter], serv.GetASMThreshold(), "tau in iteration %d", iter)
+	}
+	// ten refusers at rho 0.5 leave a shortfall of five, punished twice over
+	serv.RunStartOfIteration(2)
+	assert.Len(t, serv.getSacrificialEliminationReport(), 10)
+}
+
+func TestCatastropheStrikesOneCluster(t *testing.T) {
+	zero := 0
+	serv := newEventTestServer(config.Event{Iteration: 1, Type: config.EventCatastrophe, Cluster: &zero, Fraction: 0.5})
+	for i, agent := range serv.sortedAgents() {
+		agent.SetClusterID(i % 2)
+	}
+
+	assert.Empty(t, serv.applyCatastrophes(0), "Catastrophes only strike in their own iteration")
+	report := serv.applyCatastrophes(1)
+	assert.Len(t, report, 3, "Half of the five agents in cluster 0, rounded")
+	assert.Len(t, serv.GetAgentMap(), 7)
+	for agentID, deathInfo := range report {
+		assert.Equal(t, 0, deathInfo.Agent.GetClusterID())
+		assert.False(t, deathInfo.WasVoluntary)
+		assert.Equal(t, gameRecorder.DeathCatastrophe, serv.lineage[agentID].deathCause)
+	}
+	assert.Len(t, serv.appliedEvents, 1)
+	assert.Len(t, serv.appliedEvents[0].Agents, 3)
+}
+
+func TestTemplesClearedAndImmigrantsArrive(t *testing.T) {
+	serv := newEventTestServer(
+		config.Event{Iteration: 0, Type: config.EventClearTemples},
+		config.Event{Iteration: 0, Type: config.EventImmigration, Count: 4, Attachment: "Dismissive"},
+	)
+	serv.grid.PlaceTemple(1, 1, 0)
+	serv.grid.PlaceTemple(2, 2, 0)
+
+	serv.RunStartOfIteration(0)
+	assert.Empty(t, serv.grid.Temples())
+	assert.Len(t, serv.GetAgentMap(), 14)
+	assert.Len(t, serv.appliedEvents, 2)
+	assert.Equal(t, 2, serv.appliedEvents[0].TemplesRemoved)
+
+	for _, immigrantID := range serv.appliedEvents[1].Agents {
+		entry := serv.lineage[uuid.MustParse(immigrantID)]
+		assert.Equal(t, infra.DISMISSIVE, entry.agent.GetAttachment().Type)
+		assert.True(t, entry.agent.GetLineage().IsFounder())
+		assert.Equal(t, 0, entry.agent.GetLineage().BirthIteration)
+		assert.NotEmpty(t, entry.agent.GetNetwork(), "Immigrants join the social network")
+	}
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aaashah/TMT_FYP/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParameterSchedules(t *testing.T) {
+	constant, err := NewParameterSchedule(nil, 0.2)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.2, constant.At(50))
+
+	linear, err := NewParameterSchedule(&config.Schedule{Type: "linear", StartIteration: 10, EndIteration: 20, From: 0.1, To: 0.5}, 0.2)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.1, linear.At(0))
+	assert.InDelta(t, 0.3, linear.At(15), 1e-9)
+	assert.Equal(t, 0.5, linear.At(30))
+
+	step, err := NewParameterSchedule(&config.Schedule{Type: "step", Steps: []config.ScheduleStep{{Iteration: 5, Value: 0.6}, {Iteration: 8, Value: 0.1}}}, 0.2)
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{0.2, 0.6, 0.6, 0.1}, []float64{step.At(4), step.At(5), step.At(7), step.At(8)})
+
+	sine, err := NewParameterSchedule(&config.Schedule{Type: "sine", Mean: 0.5, Amplitude: 0.2, Period: 8}, 0.2)
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.5, sine.At(0), 1e-9)
+	assert.InDelta(t, 0.7, sine.At(2), 1e-9)
+	assert.InDelta(t, 0.3, sine.At(6), 1e-9)
+}
+
+func TestCSVSchedule(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rho.csv")
+	if err := os.WriteFile(path, []byte("iteration,rho\n0,0.1\n10,0.4\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	schedule, err := NewParameterSchedule(&config.Schedule{Type: "csv", Path: path}, 0.2)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.1, schedule.At(9))
+	assert.Equal(t, 0.4, schedule.At(10))
+
+	if err := os.WriteFile(path, []byte("0,0.1\n5,1.5\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = NewParameterSchedule(&config.Schedule{Type: "csv", Path: path}, 0.2)
+	assert.Error(t, err, "Values outside [0, 1] should be rejected")
+}
+
+func TestScheduleFeedsIterationParameters(t *testing.T) {
+	serv := newEventTestServer(config.Event{Iteration: 3, Type: config.EventRho, Value: 0.9, Duration: 1})
+	serv.rhoSchedule = LinearSchedule{Start: 0, End: 4, From: 0.1, To: 0.5}
+	serv.tauSchedule = StepSchedule{Initial: 0.5, Steps: []config.ScheduleStep{{Iteration: 2, Value: 0.25}}}
+
+	expectedRho := []float64{0.1, 0.2, 0.3, 0.9, 0.5}
+	expectedTau := []float32{0.5, 0.5, 0.25, 0.25, 0.25}
+	for iter := range expectedRho {
+		serv.RunStartOfIteration(iter)
+		assert.InDelta(t, expectedRho[iter], serv.populationRho, 1e-9, "Timeline events override the schedule")
+		assert.Equal(t, expectedTau[iter], serv.GetASMThreshold())
+	}
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/aaashah/TMT_FYP/agents"
+	"github.com/aaashah/TMT_FYP/config"
+	"github.com/aaashah/TMT_FYP/infra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpawnStrategiesKeepGridConsistent(t *testing.T) {
+	for _, strategy := range []string{"random", "parent", "centroid", "zone"} {
+		conf := config.DefaultConfig()
+		conf.NumAgents = 20
+		conf.NumIterations = 12
+		conf.NumTurns = 3
+		// a small grid fills with memorials, so later children find no room
+		conf.GridWidth = 8
+		conf.GridHeight = 8
+		conf.SpawnStrategy = strategy
+		conf.InitialExpectedChildren = 4
+		serv := CreateTMTServer(conf)
+		serv.SetGameRunner(serv)
+		serv.CreateInitialPopulation()
+		serv.Run()
+
+		assert.NoError(t, serv.grid.CheckConsistency(serv.sortedAgents()), strategy)
+		assert.LessOrEqual(t, len(serv.GetAgentMap())+len(serv.grid.Memorials()), 64, strategy)
+	}
+}
+
+func TestSpawnPlacement(t *testing.T) {
+	conf := config.DefaultConfig()
+	conf.GridWidth = 3
+	conf.GridHeight = 3
+	conf.SpawnStrategy = "zone"
+	conf.SpawnRadius = 0
+	conf.SpawnZoneX = 0
+	conf.SpawnZoneY = 0
+	serv := CreateTMTServer(conf)
+
+	first := agents.CreateSecureAgent(serv)
+	assert.True(t, serv.placeAgent(first))
+	assert.Equal(t, infra.PositionVector{X: 0, Y: 0}, first.GetPosition())
+
+	// the zone is full, so the next agent goes to the nearest free cell
+	serv.grid.PlaceTombstone(0, 1, 0)
+	second := agents.CreateSecureAgent(serv)
+	assert.True(t, serv.placeAgent(second))
+	assert.Equal(t, infra.PositionVector{X: 1, Y: 0}, second.GetPosition())
+
+	for range 6 {
+		serv.placeAgent(agents.CreateSecureAgent(serv))
+	}
+	assert.Empty(t, serv.grid.FreeCells())
+	assert.False(t, serv.placeAgent(agents.CreateSecureAgent(serv)), "A full grid has no room")
+}
+
+func TestGridConsistencyCheck(t *testing.T) {
+	serv := CreateTMTServer(config.DefaultConfig())
+	agent := agents.CreateSecureAgent(serv)
+	serv.AddAgent(agent)
+	assert.Error(t, serv.grid.CheckConsistency(serv.sortedAgents()), "An unplaced agent is not on the grid")
+
+	serv.placeAgent(agent)
+	assert.NoError(t, serv.grid.CheckConsistency(serv.sortedAgents()))
+
+	// an agent moving onto an occupied cell stays where it is
+	other := agents.CreateSecureAgent(serv)
+	serv.placeAgent(other)
+	serv.AddAgent(other)
+	assert.False(t, serv.grid.UpdateAgentPosition(other, agent.GetPosition()))
+	assert.NoError(t, serv.grid.CheckConsistency(serv.sortedAgents()))
+
+	// a dead agent's cell is handed over to its tombstone
+	serv.RemoveAgent(agent)
+	serv.involuntarilySacrificeAgent(agent, 0)
+	assert.NoError(t, serv.grid.CheckConsistency(serv.sortedAgents()))
+	assert.NotContains(t, serv.grid.GetAllOccupiedAgentPositions(), agent.GetPosition())
+}
+
+func TestFreeCellsWithoutListingTheGrid(t *testing.T) {
+	conf := config.DefaultConfig()
+	conf.GridWidth, conf.GridHeight, conf.Topology = 10, 10, "toroidal"
+	serv := CreateTMTServer(conf)
+
+	// the cells within 1.5 of a corner wrap round every edge
+	drawn := make(map[infra.PositionVector]bool)
+	for range 200 {
+		pos, ok := serv.grid.FreeCellNear(infra.Centroid{X: 0, Y: 0}, 1.5)
+		assert.True(t, ok)
+		assert.LessOrEqual(t, serv.grid.Dist(infra.PositionVector{}, pos), 1.5)
+		drawn[pos] = true
+	}
+	assert.Len(t, drawn, 9)
+
+	// a grid with one free cell left still finds it
+	for x := range 10 {
+		for y := range 10 {
+			if x != 7 || y != 3 {
+				serv.grid.PlaceTombstone(x, y, 0)
+			}
+		}
+	}
+	pos, ok := serv.grid.RandomFreeCell()
+	assert.True(t, ok)
+	assert.Equal(t, infra.PositionVector{X: 7, Y: 3}, pos)
+}
@@ -4,7 +4,8 @@ import (
 	"fmt"
 	"maps"
 	"math"
-	"math/rand"
+	"math/rand/v2"
+	"slices"
 
 	"github.com/MattSScott/basePlatformSOMAS/v2/pkg/server"
 
@@ -19,42 +20,188 @@ type TMTServer struct {
 	config                   config.Config
 	grid                     *infra.Grid
 	clusterMap               map[int][]uuid.UUID // Map of cluster IDs to agent IDs
+	clustering               kMeansResult        // k-means run behind clusterMap
 	lastEliminatedAgents     []infra.IExtendedAgent
 	lastSelfSacrificedAgents []infra.IExtendedAgent
 	numVolunteeredAgents     int
+	numAbstainedAgents       int
+	numRefusedAgents         int
+	numRequiredSacrifices    int
 	expectedChildren         float64
+	populationRho            float64 // rho and tau in force this iteration, after schedules and timeline events
+	asmThreshold             float64
+	appliedEvents            []gameRecorder.JSONEventRecord
+	starvedAgents            []infra.IExtendedAgent // starved at the end of this iteration
+	clusterQuotas            map[int]*clusterQuota  // per-cluster quotas and R0 when config.ClusterQuotas is set
+	inheritedR0              map[uuid.UUID]float64  // R0 of each agent's last cluster
 	agentDecisionThresholds  map[uuid.UUID]float64
-	gameRecorder             *gameRecorder.GameJSONRecord
-	JSONTurnLogs             []gameRecorder.TurnJSONRecord
+	agentDecisions           map[uuid.UUID]gameRecorder.JSONDecisionRecord
+	paths                    map[uuid.UUID]*plannedPath // routes planned this iteration, by agent
+	asmWeights               map[infra.AttachmentType]config.ASMWeights
+	decisionRule             infra.DecisionRule
+	eliminationPolicy        EliminationPolicy
+	rhoSchedule              ParameterSchedule
+	tauSchedule              ParameterSchedule
+	recorder                 *gameRecorder.StreamRecorder // nil when the run is not being logged
+	runDir                   string                       // empty when the run is not being logged
+	rng                      *rand.Rand                   // single seeded source for every random draw in the run
+	rngSource                *rand.PCG                    // rng's state, kept for checkpoints
+	startIteration           int                          // first iteration to play, after the checkpoint of a resumed run
+	summary                  *RunSummary
+	lineage                  map[uuid.UUID]*lineageEntry // every agent ever added, including the dead
+	lineageOrder             []uuid.UUID
 }
 
 func CreateTMTServer(config config.Config) *TMTServer {
+	decisionRule, err := infra.NewDecisionRule(config)
+	if err != nil {
+		panic(err)
+	}
+	eliminationPolicy, err := NewEliminationPolicy(config)
+	if err != nil {
+		panic(err)
+	}
+	rhoSchedule, err := NewParameterSchedule(config.RhoSchedule, config.PopulationRho)
+	if err != nil {
+		panic(err)
+	}
+	tauSchedule, err := NewParameterSchedule(config.TauSchedule, config.ASMThreshold)
+	if err != nil {
+		panic(err)
+	}
+	topology, err := infra.NewTopology(config.Topology, config.GridWidth, config.GridHeight)
+	if err != nil {
+		panic(err)
+	}
+	neighbourhood, err := infra.NewNeighbourhood(config.Neighbourhood)
+	if err != nil {
+		panic(err)
+	}
+	seed := uint64(config.Seed)
+	rngSource := rand.NewPCG(seed, seed)
+	rng := rand.New(rngSource)
+	grid := infra.NewGrid(config.GridWidth, config.GridHeight, rng)
+	grid.Topology = topology
+	grid.Neighbourhood = neighbourhood
+	grid.Aging = infra.MemorialAging{Decay: config.MemorialDecay, Lifetime: config.MemorialLifetime, PermanentTemples: config.PermanentTemples}
+	if config.Resources {
+		grid.Food = infra.NewResourceField(config.GridWidth, config.GridHeight, config.FoodCapacity, config.FoodRegrowth)
+	}
 	return &TMTServer{
 		BaseServer:               server.CreateBaseServer[infra.IExtendedAgent](config.NumIterations, config.NumTurns, 0, 0),
 		config:                   config,
-		grid:                     infra.NewGrid(config.GridWidth, config.GridHeight),
+		grid:                     grid,
 		clusterMap:               make(map[int][]uuid.UUID),
 		lastEliminatedAgents:     make([]infra.IExtendedAgent, 0),
 		lastSelfSacrificedAgents: make([]infra.IExtendedAgent, 0),
 		numVolunteeredAgents:     0,
 		expectedChildren:         config.InitialExpectedChildren,
+		populationRho:            rhoSchedule.At(0),
+		asmThreshold:             tauSchedule.At(0),
+		clusterQuotas:            make(map[int]*clusterQuota),
+		inheritedR0:              make(map[uuid.UUID]float64),
 		agentDecisionThresholds:  make(map[uuid.UUID]float64),
-		gameRecorder:             gameRecorder.MakeGameRecord(config),
-		JSONTurnLogs:             make([]gameRecorder.TurnJSONRecord, 0),
+		agentDecisions:           make(map[uuid.UUID]gameRecorder.JSONDecisionRecord),
+		paths:                    make(map[uuid.UUID]*plannedPath),
+		asmWeights:               weightsByAttachment(config),
+		decisionRule:             decisionRule,
+		eliminationPolicy:        eliminationPolicy,
+		rhoSchedule:              rhoSchedule,
+		tauSchedule:              tauSchedule,
+		rng:                      rng,
+		rngSource:                rngSource,
+		summary:                  newRunSummary(),
+		lineage:                  make(map[uuid.UUID]*lineageEntry),
+		lineageOrder:             make([]uuid.UUID, 0),
 	}
 }
 
+// Start runs the simulation and writes its logs and manifest to a new run directory
 func (tserv *TMTServer) Start() {
+	manifest := gameRecorder.NewRunManifest(tserv.config)
+	manifest.ResumedFrom = tserv.config.Resume
+	runDir, err := gameRecorder.MakeRunDirectory(tserv.config.OutputDir, manifest.RunID)
+	if err != nil {
+		panic(err)
+	}
+	recorder, err := gameRecorder.CreateStreamFile(runDir, tserv.config)
+	if err != nil {
+		panic(err)
+	}
+	tserv.AttachRecorder(recorder)
+	tserv.runDir = runDir
+
+	// the log is flushed and the manifest written even if the run panics partway through
+	defer func() {
+		failure := recover()
+		if err := recorder.Close(); err != nil {
+			fmt.Println(err)
+		}
+		manifest.Finish(len(tserv.GetAgentMap()), failure)
+		if err := gameRecorder.WriteManifest(runDir, manifest); err != nil {
+			fmt.Println(err)
+		}
+		if failure != nil {
+			panic(failure)
+		}
+	}()
+
+	tserv.Run()
+	if err := gameRecorder.WriteLineageCSV(runDir, tserv.GetLineageRecords()); err != nil {
+		panic(err)
+	}
+	if err := gameRecorder.MarkLatestRun(tserv.config.OutputDir, runDir); err != nil {
+		panic(err)
+	}
+	fmt.Printf("Run %s written to %s\n", manifest.RunID, runDir)
+}
+
+// Run plays the whole simulation without writing any logs to disk
+func (tserv *TMTServer) Run() {
+	if tserv.startIteration > 0 {
+		tserv.runFrom(tserv.startIteration)
+		return
+	}
+	tserv.summary.InitialPopulation = len(tserv.GetAgentMap())
+	tserv.registerLineage(tserv.sortedAgents())
 	// Initialize social network after agents are created
-	for _, ag := range tserv.GetAgentMap() {
+	for _, ag := range tserv.sortedAgents() {
 		tserv.InitialiseRandomNetworkForAgent(ag)
 	}
 	tserv.BaseServer.Start()
-	err := gameRecorder.WriteJSONLog("JSONlogs", tserv.gameRecorder)
-	if err != nil {
-		fmt.Println(tserv.config)
-		panic(err)
+}
+
+// runFrom plays the iterations left after a checkpoint. BaseServer.Start always
+// begins at iteration 0, and its per-turn hooks only manage asynchronous
+// messaging, which this simulation does not use.
+func (tserv *TMTServer) runFrom(first int) {
+	for i := first; i < tserv.GetIterations(); i++ {
+		tserv.RunStartOfIteration(i)
+		for j := range tserv.GetTurns() {
+			tserv.RunTurn(i, j)
+		}
+		tserv.RunEndOfIteration(i)
+	}
+}
+
+// sortedAgents returns the agent map's values in ID order
+func (tserv *TMTServer) sortedAgents() []infra.IExtendedAgent {
+	agentMap := tserv.GetAgentMap()
+	sorted := make([]infra.IExtendedAgent, 0, len(agentMap))
+	for _, agentID := range infra.SortedIDs(agentMap) {
+		sorted = append(sorted, agentMap[agentID])
 	}
+	return sorted
+}
+
+// AttachRecorder streams the run's turn and iteration records to recorder.
+// Runs without a recorder, such as sweep runs, only keep their RunSummary.
+func (tserv *TMTServer) AttachRecorder(recorder *gameRecorder.StreamRecorder) {
+	tserv.recorder = recorder
+}
+
+func (tserv *TMTServer) GetRNG() *rand.Rand {
+	return tserv.rng
 }
 
 func (tserv *TMTServer) GetAgentByID(agentID uuid.UUID) (infra.IExtendedAgent, bool) {
@@ -63,8 +210,31 @@ func (tserv *TMTServer) GetAgentByID(agentID uuid.UUID) (infra.IExtendedAgent, b
 	return agent, exists
 }
 
+// weightsByAttachment resolves the per-attachment ASM weight overrides once, up front
+func weightsByAttachment(cfg config.Config) map[infra.AttachmentType]config.ASMWeights {
+	asmWeights := make(map[infra.AttachmentType]config.ASMWeights, len(infra.AllAttachmentTypes))
+	for _, attachType := range infra.AllAttachmentTypes {
+		asmWeights[attachType] = cfg.WeightsFor(attachType.String())
+	}
+	return asmWeights
+}
+
+// GetASMWeights returns the sub-score weights used by agents of the given attachment type
+func (tserv *TMTServer) GetASMWeights(attachType infra.AttachmentType) config.ASMWeights {
+	return tserv.asmWeights[attachType]
+}
+
+// GetSpeed returns how many steps per turn agents of the given attachment type take
+func (tserv *TMTServer) GetSpeed(attachType infra.AttachmentType) int {
+	return tserv.config.Speeds.SpeedFor(attachType.String())
+}
+
+func (tserv *TMTServer) GetDecisionRule() infra.DecisionRule {
+	return tserv.decisionRule
+}
+
 func (tserv *TMTServer) GetASMThreshold() float32 {
-	return float32(tserv.config.ASMThreshold)
+	return float32(tserv.asmThreshold)
 }
 
 func (tserv *TMTServer) InitialiseRandomNetworkForAgent(agent infra.IExtendedAgent) {
@@ -73,20 +243,20 @@ func (tserv *TMTServer) InitialiseRandomNetworkForAgent(agent infra.IExtendedAge
 	agent.AddToSocialNetwork(thisAgentID, 0.5)
 
 	// add others with probability p
-	for otherAgentID := range tserv.GetAgentMap() {
+	for _, otherAgentID := range infra.SortedIDs(tserv.GetAgentMap()) {
 		// avoid overwriting existing connection
 		if agent.ExistsInNetwork(otherAgentID) {
 			continue
 		}
-		probability := rand.Float64() // Generate a random number
+		probability := tserv.rng.Float64() // Generate a random number
 		// Connect with probability p
 		if probability > tserv.config.ConnectionProbability {
 			continue
 		}
 		// Assign a random relationship strength (0.2 to 1.0)
-		strength1 := 0.2 + rand.Float32()*0.8
+		strength1 := 0.2 + tserv.rng.Float32()*0.8
 		tserv.CreateNetworkConnection(thisAgentID, otherAgentID, strength1)
-		strength2 := 0.2 + rand.Float32()*0.8
+		strength2 := 0.2 + tserv.rng.Float32()*0.8
 		tserv.CreateNetworkConnection(otherAgentID, thisAgentID, strength2)
 	}
 }
@@ -99,28 +269,12 @@ func (tserv *TMTServer) RunStartOfIteration(iteration int) {
 		fmt.Printf("Total agents: %d\n", len(tserv.GetAgentMap()))
 	}
 	// Clear memory for iteration
-	tserv.JSONTurnLogs = nil
 	clear(tserv.agentDecisionThresholds)
-}
-
-func getStep(current, target int) int {
-	if target > current {
-		return 1
-	} else if target < current {
-		return -1
-	}
-	return 0
-}
-
-func (tServ *TMTServer) moveIsValid(moveX, moveY int) bool {
-	grid := tServ.grid
-	if moveX < 0 || moveX >= grid.Width {
-		return false
-	}
-	if moveY < 0 || moveY >= grid.Height {
-		return false
-	}
-	return !grid.IsOccupied(moveX, moveY)
+	clear(tserv.agentDecisions)
+	// routes are replanned each iteration, so a resumed run plans the same ones
+	clear(tserv.paths)
+	tserv.grid.AgeMemorials(iteration)
+	tserv.applyScheduledEvents(iteration)
 }
 
 func (tserv *TMTServer) RunTurn(i, j int) {
@@ -128,7 +282,13 @@ func (tserv *TMTServer) RunTurn(i, j int) {
 		fmt.Printf("Iteration %d, Turn %d\n", i, j)
 	}
 	tserv.moveAgents()
-	tserv.recordTurnJSON(j)
+	if tserv.grid.Food != nil {
+		tserv.grid.Food.Regrow()
+	}
+	if tserv.config.Debug {
+		tserv.checkGrid(fmt.Sprintf("after turn %d of iteration %d", j, i))
+	}
+	tserv.recordTurnJSON(i, j)
 }
 
 func (tserv *TMTServer) RunEndOfIteration(iter int) {
@@ -139,6 +299,10 @@ func (tserv *TMTServer) RunEndOfIteration(iter int) {
 
 	// 2. Apply clustering (k-means)
 	tserv.applyClustering()
+	catastropheReport := tserv.applyCatastrophes(iter)
+	if tserv.config.ClusterQuotas {
+		tserv.updateClusterQuotas()
+	}
 
 	// 4. Check for agent elimination
 	tserv.updateAgentMortality()
@@ -146,18 +310,25 @@ func (tserv *TMTServer) RunEndOfIteration(iter int) {
 	naturalDeathReport := tserv.getNaturalEliminationReport()
 	tserv.applyElimination(naturalDeathReport)
 
-	// 4.2 - unnatural deaths (sacrifice)
+	// 4.2 - starvation
+	starvationReport := tserv.applyStarvation(iter)
+
+	// 4.3 - unnatural deaths (sacrifice)
 	sacrificialDeathReport := tserv.getSacrificialEliminationReport()
 	tserv.applyElimination(sacrificialDeathReport)
 
-	// 4.3 - create tombstones / temples
-	fullDeathReport := make(map[uuid.UUID]infra.DeathInfo, len(naturalDeathReport)+len(sacrificialDeathReport))
+	// 4.4 - create tombstones / temples
+	fullDeathReport := make(map[uuid.UUID]infra.DeathInfo, len(catastropheReport)+len(naturalDeathReport)+len(starvationReport)+len(sacrificialDeathReport))
+	maps.Copy(fullDeathReport, catastropheReport)
 	maps.Copy(fullDeathReport, naturalDeathReport)
+	maps.Copy(fullDeathReport, starvationReport)
 	maps.Copy(fullDeathReport, sacrificialDeathReport)
-	tserv.performSacrifices(fullDeathReport)
+	tserv.performSacrifices(fullDeathReport, iter)
+	tserv.recordLineageDeaths(iter, naturalDeathReport, sacrificialDeathReport)
 
 	// 5. After eliminations for agents in each cluster:
-	for _, agents := range tserv.clusterMap {
+	for _, clusterID := range slices.Sorted(maps.Keys(tserv.clusterMap)) {
+		agents := tserv.clusterMap[clusterID]
 		// 5.1 - Update social network (create/ cut links)
 		tserv.updateSocialNetwork(agents)
 		// 5.2 - Apply PTS protocol
@@ -171,25 +342,35 @@ func (tserv *TMTServer) RunEndOfIteration(iter int) {
 
 	// 7. Spawn new agents
 	tserv.updateProbabilityOfChildren(initialPop)
+	if tserv.config.ClusterQuotas {
+		tserv.updateClusterR0()
+	}
 
 	// Age up all agents
 	for _, agent := range tserv.GetAgentMap() {
 		agent.IncrementAge()
 	}
 
-	newAgents := tserv.generateNewAgents()
+	newAgents := tserv.placeAgents(tserv.generateNewAgents(iter))
 	newPop := initialPop + len(newAgents)
 	tserv.updateAgentWorldviews(initialPop, newPop)
 
 	tserv.spawnNewAgents(newAgents)
 
 	tserv.addIterationJSON(iter)
+	tserv.recordNetworkSnapshot(iter)
+	tserv.updateRunSummary(iter)
+	if tserv.config.Debug {
+		tserv.checkGrid(fmt.Sprintf("at the end of iteration %d", iter))
+	}
+	tserv.recordCheckpoint(iter)
 }
 
 func (tserv *TMTServer) spawnNewAgents(newAgents []infra.IExtendedAgent) {
 	for _, ag := range newAgents {
 		tserv.AddAgent(ag)
 	}
+	tserv.registerLineage(newAgents)
 
 	for _, ag := range newAgents {
 		tserv.InitialiseRandomNetworkForAgent(ag)
@@ -197,98 +378,36 @@ func (tserv *TMTServer) spawnNewAgents(newAgents []infra.IExtendedAgent) {
 }
 
 // ---------------------- Helper Functions ----------------------
-func runKMeans(positionMap map[uuid.UUID]infra.PositionVector, numClusters int) map[uuid.UUID]int {
-	numPositions := len(positionMap)
-	if numPositions == 0 {
-		return nil
-	}
-	// ----- Initialize centroids -----
-	// sample randomly from list of agent positions
-	availablePositions := make([]infra.PositionVector, 0)
-	for _, pos := range positionMap {
-		availablePositions = append(availablePositions, pos)
-	}
-	centroids := make([]*infra.Centroid, numClusters)
-	for i := range numClusters {
-		randPos := rand.Intn(numPositions)
-		samplePoint := availablePositions[randPos]
-		centroids[i] = samplePoint.PositionVectorToCentroid()
-	}
-
-	// ----- Perform K-Means -----
-	clusterAssignments := make(map[uuid.UUID]int)
-	changed := true
-
-	for changed {
-		changed = false
-
-		// Assign points
-		for agentID, agentPos := range positionMap {
-			minDist := math.MaxFloat64
-			best := -1
-			for j, centroid := range centroids {
-				agentDist := agentPos.CentroidDist(centroid)
-				if agentDist < minDist {
-					minDist = agentDist
-					best = j
-				}
-			}
-
-			if assignedPosition, agentIsAssigned := clusterAssignments[agentID]; !agentIsAssigned || assignedPosition != best {
-				clusterAssignments[agentID] = best
-				changed = true
-			}
-
-		}
-
-		// ----- Update Clusters -----
-		// record mean cluster position with total size...
-		clusterSize := make([]int, numClusters)
-		// ...and number of elements
-		clusterSum := make([]*infra.PositionVector, numClusters)
-
-		for i := range clusterSum {
-			clusterSum[i] = &infra.PositionVector{X: 0, Y: 0}
-		}
-
-		for agentID, assignedCluster := range clusterAssignments {
-			agentPos := positionMap[agentID]
-			clusterSum[assignedCluster].X += agentPos.X
-			clusterSum[assignedCluster].Y += agentPos.Y
-			clusterSize[assignedCluster]++
-		}
-
-		for i := range numClusters {
-			size := clusterSize[i]
-			if size == 0 {
-				continue
-			}
-			centroids[i].X = float64(clusterSum[i].X) / float64(size)
-			centroids[i].Y = float64(clusterSum[i].Y) / float64(size)
-		}
+func (tserv *TMTServer) moveAgents() {
+	for _, agent := range tserv.sortedAgents() {
+		tserv.moveAgent(agent)
 	}
-
-	return clusterAssignments
 }
 
-func (tserv *TMTServer) moveAgents() {
-	for _, agent := range tserv.GetAgentMap() {
-		agentPos := agent.GetPosition()
-		moveX, moveY := tserv.grid.GetValidMove(agentPos.X, agentPos.Y)
-		targetPos, posExists := agent.GetTargetPosition()
-
-		if posExists {
-			attemptX := agentPos.X + getStep(agentPos.X, targetPos.X)
-			attemptY := agentPos.Y + getStep(agentPos.Y, targetPos.Y)
-			if tserv.moveIsValid(attemptX, attemptY) {
-				moveX, moveY = attemptX, attemptY
-			}
+// moveAgent takes up to the agent's speed in steps, along a planned route to its
+// target if it has one. Without a target it forages if the grid has food and
+// wanders at random if not. An agent whose way is blocked waits for the next
+// turn. Wherever it ends up, it eats.
+func (tserv *TMTServer) moveAgent(agent infra.IExtendedAgent) {
+	targetPos, hasTarget := agent.GetTargetPosition()
+	for range agent.GetSpeed() {
+		var newPos infra.PositionVector
+		var ok bool
+		if hasTarget {
+			newPos, ok = tserv.nextStepToward(agent, targetPos)
+		} else if tserv.grid.Food != nil {
+			newPos, ok = tserv.forageStep(agent.GetPosition())
+		} else {
+			newPos, ok = tserv.grid.RandomStep(agent.GetPosition())
+		}
+		if !ok || !tserv.grid.UpdateAgentPosition(agent, newPos) {
+			break
 		}
-
-		newPos := infra.PositionVector{X: moveX, Y: moveY}
-		tserv.grid.UpdateAgentPosition(agent, newPos)
 		agent.SetPosition(newPos)
 	}
+	if tserv.grid.Food != nil {
+		tserv.feed(agent)
+	}
 }
 
 func (tserv *TMTServer) applyClustering() {
@@ -304,16 +423,16 @@ func (tserv *TMTServer) applyClustering() {
 		agentPositionMap[agentID] = pos
 	}
 
-	clusterAssignments := runKMeans(agentPositionMap, tserv.config.NumClusters)
+	tserv.clustering = runKMeans(agentPositionMap, tserv.config.NumClusters, tserv.rng, tserv.grid.Topology, tserv.kMeansOptions())
 
-	for agentID, assigment := range clusterAssignments {
+	for agentID, assigment := range tserv.clustering.assignments {
 		if agent, ok := tserv.GetAgentByID(agentID); ok {
 			agent.SetClusterID(assigment)
 		}
 	}
 
 	tserv.clusterMap = make(map[int][]uuid.UUID)
-	for _, agent := range tserv.GetAgentMap() {
+	for _, agent := range tserv.sortedAgents() {
 		tserv.clusterMap[agent.GetClusterID()] = append(tserv.clusterMap[agent.GetClusterID()], agent.GetID())
 	}
 }
@@ -335,15 +454,15 @@ func (tserv *TMTServer) updateSocialNetwork(cluster []uuid.UUID) {
 			_, connected := agentSocialNetwork[otherID]
 
 			// add link based on anxiety
-			if !connected && rand.Float32() < agent.GetAttachment().Anxiety {
+			if !connected && tserv.rng.Float32() < agent.GetAttachment().Anxiety {
 				// Add symmetric relationship with random strength
-				strength := 0.2 + rand.Float32()*0.8
+				strength := 0.2 + tserv.rng.Float32()*0.8
 				tserv.CreateNetworkConnection(agentID, otherID, strength)
 				// tserv.CreateBidirectionalConnection(agentID, otherID, strength)
 			}
 
 			// remove link based on attachment
-			if connected && rand.Float32() < agent.GetAttachment().Avoidance {
+			if connected && tserv.rng.Float32() < agent.GetAttachment().Avoidance {
 				tserv.SeverNetworkConnection(agentID, otherID)
 				// agent.RemoveRelationship(otherID)
 				// tserv.RemoveRelationship(agentID, otherID)
@@ -360,7 +479,7 @@ func (tserv *TMTServer) applyPTS(cluster []uuid.UUID) {
 		if !ok {
 			continue
 		}
-		if rand.Float32() < sender.GetPTSParams().CheckProb {
+		if tserv.rng.Float32() < sender.GetPTSParams().CheckProb {
 			for _, receiverID := range cluster {
 				if receiverID == senderID {
 					continue // don't send to self
@@ -406,43 +525,59 @@ func (tserv *TMTServer) updateProbabilityOfChildren(initPop int) {
 	numVolunteers := tserv.numVolunteeredAgents
 	proportionOfVolunteers := float64(numVolunteers) / float64(initPop)
 
-	if proportionOfVolunteers >= tserv.config.PopulationRho {
+	if proportionOfVolunteers >= tserv.populationRho {
 		tserv.expectedChildren = math.Min(tserv.expectedChildren*1.05, tserv.config.MaxExpectedChildren)
 	} else {
 		tserv.expectedChildren = math.Max(tserv.expectedChildren*0.95, tserv.config.MinExpectedChildren)
 	}
 }
 
+func (tserv *TMTServer) GetInitialEnergy() float64 {
+	return tserv.config.InitialEnergy
+}
+
+func (tserv *TMTServer) GetProximityRadius() float64 {
+	return tserv.config.ProximityRadius
+}
+
 func (tserv *TMTServer) GetInitNumberAgents() int {
 	return tserv.config.NumAgents
 }
 
+func (tserv *TMTServer) GetGrid() *infra.Grid {
+	return tserv.grid
+}
+
 func (tserv *TMTServer) GetGridDims() (int, int) {
 	return tserv.config.GridWidth, tserv.config.GridHeight
 }
 
 // ---------------------- Recording Turn Data ----------------------
 
-func (tserv *TMTServer) recordTurnJSON(turn int) {
+func (tserv *TMTServer) recordTurnJSON(iter, turn int) {
+	if tserv.recorder == nil {
+		return
+	}
 	var allAgentRecords []gameRecorder.JSONAgentRecord
-	for _, agent := range tserv.GetAgentMap() {
+	for _, agent := range tserv.sortedAgents() {
 		record := agent.RecordAgentJSON(agent)
 		record.IsAlive = true
+		if tserv.grid.Food != nil {
+			energy := agent.GetEnergy()
+			record.Energy = &energy
+		}
 		allAgentRecords = append(allAgentRecords, record)
 	}
 
-	tombstonePositions := make([]gameRecorder.Position, len(tserv.grid.Tombstones))
-	for i, pos := range tserv.grid.Tombstones {
-		tombstonePositions[i] = gameRecorder.Position{X: pos.X, Y: pos.Y}
-	}
-
-	templePositions := make([]gameRecorder.Position, len(tserv.grid.Temples))
-	for i, pos := range tserv.grid.Temples {
-		templePositions[i] = gameRecorder.Position{X: pos.X, Y: pos.Y}
-	}
+	tombstonePositions, tombstoneAges := memorialRecords(tserv.grid.Tombstones(), iter)
+	templePositions, templeAges := memorialRecords(tserv.grid.Temples(), iter)
 
 	totalAgents := float64(len(tserv.GetAgentMap()))
-	reqElims := int(tserv.config.PopulationRho * totalAgents)
+	reqElims := int(tserv.populationRho * totalAgents)
+	if tserv.config.ClusterQuotas || tserv.config.EliminationPolicy == "cluster" {
+		// quotas are floored cluster by cluster, so report the sum that was applied
+		reqElims = tserv.numRequiredSacrifices
+	}
 
 	jsonLog := gameRecorder.TurnJSONRecord{
 		Turn:                      turn,
@@ -450,26 +585,51 @@ func (tserv *TMTServer) recordTurnJSON(turn int) {
 		EliminatedAgents:          agentsToStrings(tserv.lastEliminatedAgents),
 		TotalRequiredEliminations: reqElims,
 		TotalVolunteers:           tserv.numVolunteeredAgents,
+		TotalAbstentions:          tserv.numAbstainedAgents,
+		TotalRefusals:             tserv.numRefusedAgents,
 		SelfSacrificedAgents:      agentsToStrings(tserv.lastSelfSacrificedAgents),
 		TombstoneLocations:        tombstonePositions,
 		TempleLocations:           templePositions,
+		TombstoneAges:             tombstoneAges,
+		TempleAges:                templeAges,
 	}
 
-	tserv.JSONTurnLogs = append(tserv.JSONTurnLogs, jsonLog)
+	if err := tserv.recorder.WriteTurn(iter, jsonLog); err != nil {
+		panic(err)
+	}
 }
 
-func (tserv *TMTServer) addIterationJSON(iter int) {
-	writeMap := make(map[uuid.UUID]float64)
-	maps.Copy(writeMap, tserv.agentDecisionThresholds)
+// memorialRecords lists where memorials stand and how many iterations old they are
+func memorialRecords(memorials []infra.Memorial, iter int) ([]gameRecorder.Position, []int) {
+	positions := make([]gameRecorder.Position, len(memorials))
+	ages := make([]int, len(memorials))
+	for i, memorial := range memorials {
+		positions[i] = gameRecorder.Position{X: memorial.X, Y: memorial.Y}
+		ages[i] = memorial.Age(iter)
+	}
+	return positions, ages
+}
 
+func (tserv *TMTServer) addIterationJSON(iter int) {
+	if tserv.recorder == nil {
+		return
+	}
 	log := gameRecorder.IterationJSONRecord{
 		Iteration:      iter,
-		Turns:          tserv.JSONTurnLogs,
-		Thresholds:     writeMap,
+		Thresholds:     tserv.agentDecisionThresholds,
+		Decisions:      tserv.agentDecisions,
 		NumberOfAgents: len(tserv.GetAgentMap()),
+		Clusters:       tserv.clusterQuotaRecords(),
+		Events:         tserv.appliedEvents,
+		Rho:            tserv.populationRho,
+		Tau:            tserv.asmThreshold,
+		Resources:      tserv.resourceRecord(),
+		KMeans:         tserv.kMeansRecord(),
 	}
 
-	tserv.gameRecorder.AddIteration(log)
+	if err := tserv.recorder.WriteIteration(log); err != nil {
+		panic(err)
+	}
 }
 
 func agentsToStrings(agents []infra.IExtendedAgent) []string {
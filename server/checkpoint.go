@@ -0,0 +1,222 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/aaashah/TMT_FYP/agents"
+	"github.com/aaashah/TMT_FYP/config"
+	"github.com/aaashah/TMT_FYP/infra"
+	"github.com/google/uuid"
+)
+
+// Checkpoint is the full state of a run at the end of an iteration. Restoring
+// it and playing the remaining iterations gives exactly the same run as never
+// having stopped.
+type Checkpoint struct {
+	Config           config.Config
+	Iteration        int    // last iteration played before the checkpoint
+	RNG              []byte // marshalled PCG state
+	ExpectedChildren float64
+	Agents           []AgentCheckpoint // every agent of the run, dead or alive, in lineage order
+	Occupied         []OccupiedCell
+	Tombstones       []infra.Memorial
+	Temples          []infra.Memorial
+	Food             []float64 `json:",omitempty"` // food on each cell, column by column, when the run has resources
+	ClusterMap       map[int][]uuid.UUID
+	InheritedR0      map[uuid.UUID]float64
+	// the previous iteration's sacrifices, still shown in the next iteration's turn logs
+	EliminatedAgents     []uuid.UUID
+	SelfSacrificedAgents []uuid.UUID
+	NumVolunteers        int
+	NumAbstentions       int
+	NumRefusals          int
+	NumRequired          int
+	Summary              RunSummary
+}
+
+type AgentCheckpoint struct {
+	State          infra.AgentState
+	InPopulation   bool // false once the agent has been removed from the server
+	DeathIteration int
+	DeathCause     string
+}
+
+// OccupiedCell is a grid cell the grid considers taken by an agent
+type OccupiedCell struct {
+	Position infra.PositionVector
+	AgentID  uuid.UUID
+}
+
+// Checkpoint snapshots the server after iteration iter has been played
+func (tserv *TMTServer) Checkpoint(iter int) *Checkpoint {
+	rngState, err := tserv.rngSource.MarshalBinary()
+	if err != nil {
+		panic(err)
+	}
+	agentMap := tserv.GetAgentMap()
+	var food []float64
+	if tserv.grid.Food != nil {
+		food = slices.Clone(tserv.grid.Food.Food)
+	}
+	checkpoint := &Checkpoint{
+		Config:               tserv.config,
+		Iteration:            iter,
+		RNG:                  rngState,
+		ExpectedChildren:     tserv.expectedChildren,
+		Agents:               make([]AgentCheckpoint, 0, len(tserv.lineageOrder)),
+		Occupied:             make([]OccupiedCell, 0),
+		Tombstones:           tserv.grid.Tombstones(),
+		Temples:              tserv.grid.Temples(),
+		Food:                 food,
+		ClusterMap:           make(map[int][]uuid.UUID, len(tserv.clusterMap)),
+		InheritedR0:          make(map[uuid.UUID]float64, len(tserv.inheritedR0)),
+		EliminatedAgents:     agentIDs(tserv.lastEliminatedAgents),
+		SelfSacrificedAgents: agentIDs(tserv.lastSelfSacrificedAgents),
+		NumVolunteers:        tserv.numVolunteeredAgents,
+		NumAbstentions:       tserv.numAbstainedAgents,
+		NumRefusals:          tserv.numRefusedAgents,
+		NumRequired:          tserv.numRequiredSacrifices,
+		Summary:              tserv.GetRunSummary(),
+	}
+	for _, agentID := range tserv.lineageOrder {
+		entry := tserv.lineage[agentID]
+		_, inPopulation := agentMap[agentID]
+		checkpoint.Agents = append(checkpoint.Agents, AgentCheckpoint{
+			State:          entry.agent.GetState(),
+			InPopulation:   inPopulation,
+			DeathIteration: entry.deathIteration,
+			DeathCause:     entry.deathCause,
+		})
+	}
+	for pos, agent := range tserv.grid.GetAllOccupiedAgentPositions() {
+		checkpoint.Occupied = append(checkpoint.Occupied, OccupiedCell{Position: pos, AgentID: agent.GetID()})
+	}
+	slices.SortFunc(checkpoint.Occupied, func(a, b OccupiedCell) int {
+		if a.Position.X != b.Position.X {
+			return a.Position.X - b.Position.X
+		}
+		return a.Position.Y - b.Position.Y
+	})
+	for clusterID, members := range tserv.clusterMap {
+		checkpoint.ClusterMap[clusterID] = slices.Clone(members)
+	}
+	for agentID, r0 := range tserv.inheritedR0 {
+		checkpoint.InheritedR0[agentID] = r0
+	}
+	return checkpoint
+}
+
+// RestoreTMTServer rebuilds the server a checkpoint was taken from. cfg is
+// normally the checkpoint's own Config; a changed one forks the run, which
+// then replays the same history under the new parameters.
+func RestoreTMTServer(cfg config.Config, checkpoint *Checkpoint) *TMTServer {
+	tserv := CreateTMTServer(cfg)
+	if err := tserv.rngSource.UnmarshalBinary(checkpoint.RNG); err != nil {
+		panic(err)
+	}
+
+	restored := make(map[uuid.UUID]infra.IExtendedAgent, len(checkpoint.Agents))
+	for _, saved := range checkpoint.Agents {
+		agent := agents.RestoreAgent(tserv, saved.State)
+		agentID := agent.GetID()
+		restored[agentID] = agent
+		tserv.lineage[agentID] = &lineageEntry{agent: agent, deathIteration: saved.DeathIteration, deathCause: saved.DeathCause}
+		tserv.lineageOrder = append(tserv.lineageOrder, agentID)
+		if saved.InPopulation {
+			tserv.AddAgent(agent)
+		}
+	}
+	lookup := func(agentID uuid.UUID) infra.IExtendedAgent {
+		agent, ok := restored[agentID]
+		if !ok {
+			panic(fmt.Sprintf("checkpoint refers to unknown agent %v", agentID))
+		}
+		return agent
+	}
+
+	for _, cell := range checkpoint.Occupied {
+		tserv.grid.PlaceAgent(lookup(cell.AgentID), cell.Position)
+	}
+	tserv.grid.RestoreMemorials(checkpoint.Tombstones, checkpoint.Temples)
+	if tserv.grid.Food != nil {
+		copy(tserv.grid.Food.Food, checkpoint.Food)
+	}
+
+	for clusterID, members := range checkpoint.ClusterMap {
+		tserv.clusterMap[clusterID] = slices.Clone(members)
+	}
+	for agentID, r0 := range checkpoint.InheritedR0 {
+		tserv.inheritedR0[agentID] = r0
+	}
+	for _, agentID := range checkpoint.EliminatedAgents {
+		tserv.lastEliminatedAgents = append(tserv.lastEliminatedAgents, lookup(agentID))
+	}
+	for _, agentID := range checkpoint.SelfSacrificedAgents {
+		tserv.lastSelfSacrificedAgents = append(tserv.lastSelfSacrificedAgents, lookup(agentID))
+	}
+	tserv.numVolunteeredAgents = checkpoint.NumVolunteers
+	tserv.numAbstainedAgents = checkpoint.NumAbstentions
+	tserv.numRefusedAgents = checkpoint.NumRefusals
+	tserv.numRequiredSacrifices = checkpoint.NumRequired
+	tserv.expectedChildren = checkpoint.ExpectedChildren
+
+	summary := checkpoint.Summary
+	if summary.FinalAttachmentCounts == nil {
+		summary.FinalAttachmentCounts = make(map[infra.AttachmentType]int)
+	}
+	tserv.summary = &summary
+	tserv.startIteration = checkpoint.Iteration + 1
+	return tserv
+}
+
+func agentIDs(agentList []infra.IExtendedAgent) []uuid.UUID {
+	ids := make([]uuid.UUID, len(agentList))
+	for i, agent := range agentList {
+		ids[i] = agent.GetID()
+	}
+	return ids
+}
+
+// LoadCheckpoint reads a checkpoint written by WriteCheckpoint
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	checkpoint := &Checkpoint{}
+	if err := json.Unmarshal(data, checkpoint); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint %s: %w", path, err)
+	}
+	return checkpoint, nil
+}
+
+// WriteCheckpoint writes checkpoints/iteration_NNNN.json inside the run directory
+func WriteCheckpoint(runDir string, checkpoint *Checkpoint) error {
+	checkpointDir := filepath.Join(runDir, "checkpoints")
+	if err := os.MkdirAll(checkpointDir, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+	path := filepath.Join(checkpointDir, fmt.Sprintf("iteration_%04d.json", checkpoint.Iteration))
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return nil
+}
+
+func (tserv *TMTServer) recordCheckpoint(iter int) {
+	every := tserv.config.CheckpointEvery
+	if tserv.runDir == "" || every <= 0 || iter%every != 0 {
+		return
+	}
+	if err := WriteCheckpoint(tserv.runDir, tserv.Checkpoint(iter)); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"maps"
+	"math"
+	"slices"
+
+	"github.com/aaashah/TMT_FYP/gameRecorder"
+	"github.com/aaashah/TMT_FYP/infra"
+	"github.com/google/uuid"
+)
+
+// clusterQuota is one k-means cluster's sacrifice quota and R0 in the current iteration
+type clusterQuota struct {
+	size             int // living agents when sacrifices are decided
+	required         int
+	volunteers       int
+	expectedChildren float64
+}
+
+func (cq *clusterQuota) metQuota() bool {
+	return cq.volunteers >= cq.required
+}
+
+// updateClusterQuotas starts a new iteration's quotas after clustering. Cluster IDs are
+// relabelled by every k-means run, so each agent carries the R0 of its last cluster and
+// a cluster's R0 is the mean over its members.
+func (tserv *TMTServer) updateClusterQuotas() {
+	tserv.clusterQuotas = make(map[int]*clusterQuota)
+	inheritedR0 := make(map[uuid.UUID]float64, len(tserv.inheritedR0))
+	agentMap := tserv.GetAgentMap()
+	for _, clusterID := range slices.Sorted(maps.Keys(tserv.clusterMap)) {
+		members := 0
+		total := 0.0
+		// clusterMap is left as it was when nobody is left to cluster
+		for _, agentID := range tserv.clusterMap[clusterID] {
+			if _, alive := agentMap[agentID]; !alive {
+				continue
+			}
+			r0, ok := tserv.inheritedR0[agentID]
+			if !ok {
+				r0 = tserv.config.InitialExpectedChildren
+			}
+			inheritedR0[agentID] = r0
+			total += r0
+			members++
+		}
+		if members > 0 {
+			tserv.clusterQuotas[clusterID] = &clusterQuota{expectedChildren: total / float64(members)}
+		}
+	}
+	tserv.inheritedR0 = inheritedR0
+}
+
+// selectClusterEliminations applies rho and the elimination policy separately within each cluster
+func (tserv *TMTServer) selectClusterEliminations(volunteers []infra.IExtendedAgent, nonVolunteers [][]infra.IExtendedAgent) map[uuid.UUID]infra.DeathInfo {
+	report, splits := tserv.selectByCluster(tserv.eliminationPolicy, volunteers, nonVolunteers)
+	for clusterID, split := range splits {
+		quota, ok := tserv.clusterQuotas[clusterID]
+		if !ok {
+			quota = &clusterQuota{expectedChildren: tserv.config.InitialExpectedChildren}
+			tserv.clusterQuotas[clusterID] = quota
+		}
+		quota.size, quota.required, quota.volunteers = split.size, split.required, split.volunteers
+	}
+	return report
+}
+
+// selectByCluster has policy choose each cluster's sacrifices against a rho quota of the
+// cluster's own, and sets the iteration's required sacrifices to the sum of those quotas.
+// It returns the combined report and every cluster's size, quota and volunteers.
+func (tserv *TMTServer) selectByCluster(policy EliminationPolicy, volunteers []infra.IExtendedAgent, nonVolunteers [][]infra.IExtendedAgent) (map[uuid.UUID]infra.DeathInfo, map[int]clusterQuota) {
+	clusterVolunteers, clusterNonVolunteers, clusterSizes := splitByCluster(volunteers, nonVolunteers)
+
+	report := make(map[uuid.UUID]infra.DeathInfo)
+	splits := make(map[int]clusterQuota, len(clusterSizes))
+	totalRequired := 0
+	for _, clusterID := range slices.Sorted(maps.Keys(clusterSizes)) {
+		split := clusterQuota{
+			size:       clusterSizes[clusterID],
+			required:   int(tserv.populationRho * float64(clusterSizes[clusterID])),
+			volunteers: len(clusterVolunteers[clusterID]),
+		}
+		splits[clusterID] = split
+		totalRequired += split.required
+
+		clusterReport := policy.SelectEliminations(tserv, clusterVolunteers[clusterID], clusterNonVolunteers[clusterID], split.required)
+		maps.Copy(report, clusterReport)
+	}
+	tserv.numRequiredSacrifices = totalRequired
+	return report, splits
+}
+
+// updateClusterR0 applies the updateProbabilityOfChildren feedback to each cluster's own
+// volunteering rate and passes the new R0 on to the cluster's surviving members
+func (tserv *TMTServer) updateClusterR0() {
+	agentMap := tserv.GetAgentMap()
+	for _, clusterID := range slices.Sorted(maps.Keys(tserv.clusterQuotas)) {
+		quota := tserv.clusterQuotas[clusterID]
+		if quota.size == 0 {
+			continue
+		}
+		if float64(quota.volunteers)/float64(quota.size) >= tserv.populationRho {
+			quota.expectedChildren = math.Min(quota.expectedChildren*1.05, tserv.config.MaxExpectedChildren)
+		} else {
+			quota.expectedChildren = math.Max(quota.expectedChildren*0.95, tserv.config.MinExpectedChildren)
+		}
+		for _, agentID := range tserv.clusterMap[clusterID] {
+			if _, alive := agentMap[agentID]; alive {
+				tserv.inheritedR0[agentID] = quota.expectedChildren
+			} else {
+				delete(tserv.inheritedR0, agentID)
+			}
+		}
+	}
+}
+
+// generateClusterAgents breeds each cluster's dead separately at the cluster's own R0.
+// Only clusters that met their quota have children, who inherit the cluster's R0.
+func (tserv *TMTServer) generateClusterAgents(iter int, spacesAvailable *int) []infra.IExtendedAgent {
+	parentPools := make(map[int][]infra.IExtendedAgent)
+	for _, agent := range tserv.lastEliminatedAgents {
+		parentPools[agent.GetClusterID()] = append(parentPools[agent.GetClusterID()], agent)
+	}
+
+	newAgents := make([]infra.IExtendedAgent, 0)
+	for _, clusterID := range slices.Sorted(maps.Keys(parentPools)) {
+		quota, ok := tserv.clusterQuotas[clusterID]
+		if !ok || !quota.metQuota() {
+			continue
+		}
+		for _, child := range tserv.breedFromPool(parentPools[clusterID], quota.expectedChildren, iter, spacesAvailable) {
+			tserv.inheritedR0[child.GetID()] = quota.expectedChildren
+			newAgents = append(newAgents, child)
+		}
+	}
+	return newAgents
+}
+
+// splitByCluster groups volunteers and each tier of non-volunteers by cluster ID
+func splitByCluster(volunteers []infra.IExtendedAgent, nonVolunteers [][]infra.IExtendedAgent) (map[int][]infra.IExtendedAgent, map[int][][]infra.IExtendedAgent, map[int]int) {
+	clusterVolunteers := make(map[int][]infra.IExtendedAgent)
+	clusterNonVolunteers := make(map[int][][]infra.IExtendedAgent)
+	clusterSizes := make(map[int]int)
+	for _, agent := range volunteers {
+		clusterID := agent.GetClusterID()
+		clusterVolunteers[clusterID] = append(clusterVolunteers[clusterID], agent)
+		clusterSizes[clusterID]++
+	}
+	for tierIndex, tier := range nonVolunteers {
+		for _, agent := range tier {
+			clusterID := agent.GetClusterID()
+			if clusterNonVolunteers[clusterID] == nil {
+				clusterNonVolunteers[clusterID] = make([][]infra.IExtendedAgent, len(nonVolunteers))
+			}
+			clusterNonVolunteers[clusterID][tierIndex] = append(clusterNonVolunteers[clusterID][tierIndex], agent)
+			clusterSizes[clusterID]++
+		}
+	}
+	return clusterVolunteers, clusterNonVolunteers, clusterSizes
+}
+
+func (tserv *TMTServer) clusterQuotaRecords() []gameRecorder.JSONClusterRecord {
+	if !tserv.config.ClusterQuotas {
+		return nil
+	}
+	records := make([]gameRecorder.JSONClusterRecord, 0, len(tserv.clusterQuotas))
+	for _, clusterID := range slices.Sorted(maps.Keys(tserv.clusterQuotas)) {
+		quota := tserv.clusterQuotas[clusterID]
+		records = append(records, gameRecorder.JSONClusterRecord{
+			ClusterID:        clusterID,
+			Size:             quota.size,
+			Required:         quota.required,
+			Volunteers:       quota.volunteers,
+			MetQuota:         quota.metQuota(),
+			ExpectedChildren: quota.expectedChildren,
+		})
+	}
+	return records
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"math/rand/v2"
+	"sync"
+
+	"github.com/aaashah/TMT_FYP/infra"
+)
+
+// decideAll has every agent evaluate its ASM decision against the world as it
+// stood before anyone decided, spread over the decision workers, then applies the
+// outcomes in order
+func (tserv *TMTServer) decideAll(agents []infra.IExtendedAgent) []infra.ASMDecison {
+	seed := tserv.rng.Uint64()
+	outcomes := make([]infra.ASMOutcome, len(agents))
+	evaluate := func(i int) {
+		outcomes[i] = agents[i].EvaluateASMDecision(tserv.grid, decisionRNG(seed, i))
+	}
+	if tserv.config.DecisionWorkers <= 1 {
+		for i := range agents {
+			evaluate(i)
+		}
+	} else {
+		tserv.evaluateConcurrently(len(agents), evaluate)
+	}
+
+	decisions := make([]infra.ASMDecison, len(agents))
+	for i, outcome := range outcomes {
+		agents[i].ApplyASMOutcome(outcome)
+		decisions[i] = outcome.Decision
+	}
+	return decisions
+}
+
+// decisionRNG is the generator the agent at index i of a decision phase draws
+// from. Seeding it from one draw of the server's generator and the agent's place
+// in the order makes the outcomes depend on the seed alone, not on the number of
+// workers or how they are scheduled.
+func decisionRNG(seed uint64, i int) *rand.Rand {
+	return rand.New(rand.NewPCG(seed, uint64(i)))
+}
+
+// evaluateConcurrently shares the indices 0 to count-1 out over a pool of workers
+func (tserv *TMTServer) evaluateConcurrently(count int, evaluate func(i int)) {
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(tserv.config.DecisionWorkers, count) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				evaluate(i)
+			}
+		}()
+	}
+	for i := range count {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
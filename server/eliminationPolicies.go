@@ -0,0 +1,209 @@
+package server
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/aaashah/TMT_FYP/config"
+	"github.com/aaashah/TMT_FYP/infra"
+	"github.com/google/uuid"
+)
+
+// EliminationPolicy chooses who is sacrificed in an iteration. volunteers are in ID order;
+// nonVolunteers are tiers drawn in order (e.g. abstainers before refusers), each in ID order.
+type EliminationPolicy interface {
+	SelectEliminations(tserv *TMTServer, volunteers []infra.IExtendedAgent, nonVolunteers [][]infra.IExtendedAgent, required int) map[uuid.UUID]infra.DeathInfo
+}
+
+// NewEliminationPolicy builds the policy named by cfg.EliminationPolicy
+func NewEliminationPolicy(cfg config.Config) (EliminationPolicy, error) {
+	multiplier := cfg.PunishmentMultiplier
+	switch cfg.EliminationPolicy {
+	case "multiplier":
+		return MultiplierPolicy{Multiplier: multiplier}, nil
+	case "ageLottery":
+		return LotteryPolicy{Multiplier: multiplier, Weight: ageWeight}, nil
+	case "esteemLottery":
+		return LotteryPolicy{Multiplier: multiplier, Weight: esteemWeight}, nil
+	case "cluster":
+		return ClusterQuotaPolicy{Multiplier: multiplier}, nil
+	case "centrality":
+		return CentralityPolicy{Multiplier: multiplier}, nil
+	case "none":
+		return NoPunishmentPolicy{}, nil
+	default:
+		return nil, fmt.Errorf("unknown elimination policy %q", cfg.EliminationPolicy)
+	}
+}
+
+// MultiplierPolicy sacrifices every volunteer plus Multiplier times the shortfall in
+// non-volunteers, drawn uniformly at random
+type MultiplierPolicy struct {
+	Multiplier float64
+}
+
+func (p MultiplierPolicy) SelectEliminations(tserv *TMTServer, volunteers []infra.IExtendedAgent, nonVolunteers [][]infra.IExtendedAgent, required int) map[uuid.UUID]infra.DeathInfo {
+	report, shortfall := tserv.selectVolunteers(volunteers, required)
+	if shortfall == 0 {
+		return report
+	}
+	for _, tier := range nonVolunteers {
+		tserv.shuffleAgents(tier)
+	}
+	eliminateInOrder(report, slices.Concat(nonVolunteers...), punishmentSize(p.Multiplier, shortfall))
+	return report
+}
+
+// LotteryPolicy draws the punished non-volunteers with probability proportional to Weight
+type LotteryPolicy struct {
+	Multiplier float64
+	Weight     func(agent infra.IExtendedAgent, esteem map[uuid.UUID]float64) float64
+}
+
+func (p LotteryPolicy) SelectEliminations(tserv *TMTServer, volunteers []infra.IExtendedAgent, nonVolunteers [][]infra.IExtendedAgent, required int) map[uuid.UUID]infra.DeathInfo {
+	report, shortfall := tserv.selectVolunteers(volunteers, required)
+	if shortfall == 0 {
+		return report
+	}
+	esteem := tserv.incomingEsteem()
+	remaining := punishmentSize(p.Multiplier, shortfall)
+	for _, tier := range nonVolunteers {
+		weights := make([]float64, len(tier))
+		for i, agent := range tier {
+			weights[i] = p.Weight(agent, esteem)
+		}
+		drawn := tserv.weightedSample(tier, weights, remaining)
+		eliminateInOrder(report, drawn, len(drawn))
+		remaining -= len(drawn)
+	}
+	return report
+}
+
+// older agents are more likely to be drawn; newborns still have a chance
+func ageWeight(agent infra.IExtendedAgent, esteem map[uuid.UUID]float64) float64 {
+	return float64(agent.GetAge() + 1)
+}
+
+// agents held in high esteem by others are more likely to be drawn
+func esteemWeight(agent infra.IExtendedAgent, esteem map[uuid.UUID]float64) float64 {
+	return esteem[agent.GetID()] + 1e-6
+}
+
+// ClusterQuotaPolicy applies the rho quota and the multiplier within each k-means cluster
+type ClusterQuotaPolicy struct {
+	Multiplier float64
+}
+
+func (p ClusterQuotaPolicy) SelectEliminations(tserv *TMTServer, volunteers []infra.IExtendedAgent, nonVolunteers [][]infra.IExtendedAgent, required int) map[uuid.UUID]infra.DeathInfo {
+	report, _ := tserv.selectByCluster(MultiplierPolicy{Multiplier: p.Multiplier}, volunteers, nonVolunteers)
+	return report
+}
+
+// CentralityPolicy punishes the non-volunteers held in the highest total esteem first
+type CentralityPolicy struct {
+	Multiplier float64
+}
+
+func (p CentralityPolicy) SelectEliminations(tserv *TMTServer, volunteers []infra.IExtendedAgent, nonVolunteers [][]infra.IExtendedAgent, required int) map[uuid.UUID]infra.DeathInfo {
+	report, shortfall := tserv.selectVolunteers(volunteers, required)
+	if shortfall == 0 {
+		return report
+	}
+	esteem := tserv.incomingEsteem()
+	for _, tier := range nonVolunteers {
+		// shuffle first so ties are broken at random
+		tserv.shuffleAgents(tier)
+		slices.SortStableFunc(tier, func(a, b infra.IExtendedAgent) int {
+			return cmp.Compare(esteem[b.GetID()], esteem[a.GetID()])
+		})
+	}
+	eliminateInOrder(report, slices.Concat(nonVolunteers...), punishmentSize(p.Multiplier, shortfall))
+	return report
+}
+
+// NoPunishmentPolicy only ever sacrifices volunteers, even when the quota is unmet
+type NoPunishmentPolicy struct{}
+
+func (NoPunishmentPolicy) SelectEliminations(tserv *TMTServer, volunteers []infra.IExtendedAgent, nonVolunteers [][]infra.IExtendedAgent, required int) map[uuid.UUID]infra.DeathInfo {
+	report, _ := tserv.selectVolunteers(volunteers, required)
+	return report
+}
+
+// ---------------------- Helper Functions ----------------------
+
+// selectVolunteers sacrifices required volunteers at random, or all of them if there are
+// too few, and returns how many sacrifices are still missing
+func (tserv *TMTServer) selectVolunteers(volunteers []infra.IExtendedAgent, required int) (map[uuid.UUID]infra.DeathInfo, int) {
+	report := make(map[uuid.UUID]infra.DeathInfo)
+	if len(volunteers) >= required {
+		tserv.shuffleAgents(volunteers)
+		for _, agent := range volunteers[:required] {
+			report[agent.GetID()] = infra.DeathInfo{Agent: agent, WasVoluntary: true}
+		}
+		return report, 0
+	}
+	for _, agent := range volunteers {
+		report[agent.GetID()] = infra.DeathInfo{Agent: agent, WasVoluntary: true}
+	}
+	return report, required - len(volunteers)
+}
+
+func eliminateInOrder(report map[uuid.UUID]infra.DeathInfo, agents []infra.IExtendedAgent, count int) {
+	for _, agent := range agents[:min(count, len(agents))] {
+		report[agent.GetID()] = infra.DeathInfo{Agent: agent, WasVoluntary: false}
+	}
+}
+
+func punishmentSize(multiplier float64, shortfall int) int {
+	return int(math.Round(multiplier * float64(shortfall)))
+}
+
+func (tserv *TMTServer) shuffleAgents(agents []infra.IExtendedAgent) {
+	tserv.rng.Shuffle(len(agents), func(i, j int) {
+		agents[i], agents[j] = agents[j], agents[i]
+	})
+}
+
+// weightedSample draws up to count agents without replacement, with probability proportional to weight
+func (tserv *TMTServer) weightedSample(agents []infra.IExtendedAgent, weights []float64, count int) []infra.IExtendedAgent {
+	pool := slices.Clone(agents)
+	poolWeights := slices.Clone(weights)
+	drawn := make([]infra.IExtendedAgent, 0, min(count, len(pool)))
+	for len(drawn) < count && len(pool) > 0 {
+		total := 0.0
+		for _, weight := range poolWeights {
+			total += weight
+		}
+		target := tserv.rng.Float64() * total
+		chosen := len(pool) - 1
+		for i, weight := range poolWeights {
+			target -= weight
+			if target < 0 {
+				chosen = i
+				break
+			}
+		}
+		drawn = append(drawn, pool[chosen])
+		pool = slices.Delete(pool, chosen, chosen+1)
+		poolWeights = slices.Delete(poolWeights, chosen, chosen+1)
+	}
+	return drawn
+}
+
+// incomingEsteem sums, for every agent, the relationship strengths other agents
+// hold towards it. Each agent's link to itself is left out.
+func (tserv *TMTServer) incomingEsteem() map[uuid.UUID]float64 {
+	esteem := make(map[uuid.UUID]float64)
+	for _, agent := range tserv.sortedAgents() {
+		network := agent.GetNetwork()
+		for _, friendID := range infra.SortedIDs(network) {
+			if friendID == agent.GetID() {
+				continue
+			}
+			esteem[friendID] += float64(network[friendID])
+		}
+	}
+	return esteem
+}
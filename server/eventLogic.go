@@ -0,0 +1,98 @@
+package server
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/aaashah/TMT_FYP/config"
+	"github.com/aaashah/TMT_FYP/gameRecorder"
+	"github.com/aaashah/TMT_FYP/infra"
+	"github.com/google/uuid"
+)
+
+// applyScheduledEvents sets this iteration's rho and tau from their schedules,
+// overridden by any timeline events, and applies the events due at the start
+// of the iteration
+func (tserv *TMTServer) applyScheduledEvents(iter int) {
+	tserv.appliedEvents = nil
+	baseRho := tserv.rhoSchedule.At(iter)
+	if tserv.config.ScarcityRho {
+		baseRho = tserv.scarcity()
+	}
+	tserv.populationRho = tserv.config.ParameterAt(config.EventRho, baseRho, iter)
+	tserv.asmThreshold = tserv.config.ParameterAt(config.EventTau, tserv.tauSchedule.At(iter), iter)
+
+	for _, event := range tserv.config.Events {
+		if event.Iteration != iter {
+			continue
+		}
+		record := gameRecorder.JSONEventRecord{Event: event}
+		switch event.Type {
+		case config.EventRho, config.EventTau:
+			// already in force through ParameterAt
+		case config.EventClearTemples:
+			record.TemplesRemoved = tserv.grid.ClearTemples()
+		case config.EventImmigration:
+			immigrants := tserv.placeAgents(tserv.createImmigrants(event, iter))
+			tserv.spawnNewAgents(immigrants)
+			record.Agents = agentsToStrings(immigrants)
+		case config.EventCatastrophe:
+			continue // strikes at the end of the iteration
+		}
+		tserv.appliedEvents = append(tserv.appliedEvents, record)
+	}
+}
+
+func (tserv *TMTServer) createImmigrants(event config.Event, iter int) []infra.IExtendedAgent {
+	attachType, ok := attachmentTypeByName(event.Attachment)
+	if !ok {
+		panic(fmt.Sprintf("unknown immigrant attachment style %q", event.Attachment))
+	}
+	immigrants := make([]infra.IExtendedAgent, 0, event.Count)
+	for range event.Count {
+		immigrant := tserv.createAgentOfType(attachType)
+		// immigrants found new family lines
+		immigrant.SetLineage(infra.Lineage{Generation: 0, BirthIteration: iter})
+		immigrants = append(immigrants, immigrant)
+	}
+	return immigrants
+}
+
+func attachmentTypeByName(name string) (infra.AttachmentType, bool) {
+	for _, attachType := range infra.AllAttachmentTypes {
+		if attachType.String() == name {
+			return attachType, true
+		}
+	}
+	return 0, false
+}
+
+// applyCatastrophes kills the victims of this iteration's catastrophes, once
+// the iteration's clusters are known, and returns them as a death report
+func (tserv *TMTServer) applyCatastrophes(iter int) map[uuid.UUID]infra.DeathInfo {
+	report := make(map[uuid.UUID]infra.DeathInfo)
+	for _, event := range tserv.config.Events {
+		if event.Iteration != iter || event.Type != config.EventCatastrophe {
+			continue
+		}
+		struck := make([]infra.IExtendedAgent, 0)
+		for _, agent := range tserv.sortedAgents() {
+			if event.Cluster == nil || agent.GetClusterID() == *event.Cluster {
+				struck = append(struck, agent)
+			}
+		}
+		tserv.shuffleAgents(struck)
+		victims := struck[:int(math.Round(event.Fraction*float64(len(struck))))]
+		for _, victim := range victims {
+			victim.MarkAsDead()
+			tserv.RemoveAgent(victim)
+			report[victim.GetID()] = infra.DeathInfo{Agent: victim, WasVoluntary: false}
+			tserv.recordLineageDeath(victim.GetID(), iter, gameRecorder.DeathCatastrophe)
+		}
+		tserv.appliedEvents = append(tserv.appliedEvents, gameRecorder.JSONEventRecord{
+			Event:  event,
+			Agents: agentsToStrings(victims),
+		})
+	}
+	return report
+}
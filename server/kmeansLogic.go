@@ -0,0 +1,239 @@
+package server
+
+import (
+	"math"
+	"math/rand/v2"
+
+	"github.com/aaashah/TMT_FYP/gameRecorder"
+	"github.com/aaashah/TMT_FYP/infra"
+	"github.com/google/uuid"
+)
+
+// kMeansOptions bounds each k-means run and sets how many restarts to choose from
+type kMeansOptions struct {
+	restarts      int
+	maxIterations int
+	tolerance     float64 // largest centroid shift, in cells, still counted as converged
+}
+
+// kMeansResult is the lowest-inertia clustering found by runKMeans
+type kMeansResult struct {
+	assignments map[uuid.UUID]int
+	centroids   []infra.Centroid
+	sizes       []int
+	inertia     float64 // sum of squared distances from agents to their centroids
+	iterations  int     // iterations taken by the restart that was kept
+}
+
+func (tserv *TMTServer) kMeansOptions() kMeansOptions {
+	return kMeansOptions{
+		restarts:      tserv.config.KMeansRestarts,
+		maxIterations: tserv.config.KMeansMaxIterations,
+		tolerance:     tserv.config.KMeansTolerance,
+	}
+}
+
+// runKMeans clusters the positions into numClusters groups, restarting from fresh
+// k-means++ seeds and keeping the run with the lowest inertia
+func runKMeans(positionMap map[uuid.UUID]infra.PositionVector, numClusters int, rng *rand.Rand, topology infra.Topology, options kMeansOptions) kMeansResult {
+	if len(positionMap) == 0 {
+		return kMeansResult{}
+	}
+	agentIDs := infra.SortedIDs(positionMap)
+	points := make([]infra.PositionVector, len(agentIDs))
+	for i, agentID := range agentIDs {
+		points[i] = positionMap[agentID]
+	}
+
+	var best kMeansResult
+	var bestAssignment []int
+	for restart := range max(options.restarts, 1) {
+		run := newKMeansRun(points, numClusters, topology)
+		run.seed(rng)
+		iterations := run.lloyd(options)
+		if inertia := run.inertia(); restart == 0 || inertia < best.inertia {
+			best = kMeansResult{centroids: run.centroids, sizes: run.sizes, inertia: inertia, iterations: iterations}
+			bestAssignment = run.assignment
+		}
+	}
+
+	best.assignments = make(map[uuid.UUID]int, len(agentIDs))
+	for i, agentID := range agentIDs {
+		best.assignments[agentID] = bestAssignment[i]
+	}
+	return best
+}
+
+// kMeansRun is the state of one k-means restart, with points in agent ID order
+type kMeansRun struct {
+	points     []infra.PositionVector
+	topology   infra.Topology
+	centroids  []infra.Centroid
+	assignment []int // cluster of each point, -1 before the first assignment
+	sizes      []int
+}
+
+func newKMeansRun(points []infra.PositionVector, numClusters int, topology infra.Topology) *kMeansRun {
+	assignment := make([]int, len(points))
+	for i := range assignment {
+		assignment[i] = -1
+	}
+	return &kMeansRun{
+		points:     points,
+		topology:   topology,
+		centroids:  make([]infra.Centroid, 0, numClusters),
+		assignment: assignment,
+		sizes:      make([]int, numClusters),
+	}
+}
+
+func (run *kMeansRun) dist(point infra.PositionVector, centroid infra.Centroid) float64 {
+	return infra.Distance(run.topology, float64(point.X), float64(point.Y), centroid.X, centroid.Y)
+}
+
+// seed places the first centroid on a random point and each further one on a point
+// drawn with probability proportional to its squared distance from the nearest
+// centroid so far (k-means++). With fewer distinct points than clusters the rest
+// are stacked on the first centroid and left empty.
+func (run *kMeansRun) seed(rng *rand.Rand) {
+	first := run.points[rng.IntN(len(run.points))]
+	run.centroids = append(run.centroids, *first.PositionVectorToCentroid())
+	nearest := make([]float64, len(run.points)) // squared distance to the nearest centroid
+	for i, point := range run.points {
+		nearest[i] = math.Pow(run.dist(point, run.centroids[0]), 2)
+	}
+	for len(run.centroids) < len(run.sizes) {
+		total := 0.0
+		for _, d := range nearest {
+			total += d
+		}
+		if total == 0 {
+			run.centroids = append(run.centroids, run.centroids[0])
+			continue
+		}
+		chosen := len(run.points) - 1
+		draw := rng.Float64() * total
+		for i, d := range nearest {
+			if draw < d {
+				chosen = i
+				break
+			}
+			draw -= d
+		}
+		centroid := *run.points[chosen].PositionVectorToCentroid()
+		run.centroids = append(run.centroids, centroid)
+		for i, point := range run.points {
+			nearest[i] = min(nearest[i], math.Pow(run.dist(point, centroid), 2))
+		}
+	}
+}
+
+// lloyd alternates assigning points and moving centroids until no point changes
+// cluster, no centroid moves further than the tolerance or the iteration cap is
+// reached, and returns the number of iterations taken
+func (run *kMeansRun) lloyd(options kMeansOptions) int {
+	iterations := 0
+	for iterations < options.maxIterations {
+		iterations++
+		changed := run.assign()
+		run.repairEmpty()
+		if shift := run.update(); !changed || shift <= options.tolerance {
+			break
+		}
+	}
+	return iterations
+}
+
+// assign moves every point to its nearest centroid, the lowest-numbered on a tie,
+// and reports whether any point changed cluster
+func (run *kMeansRun) assign() bool {
+	changed := false
+	clear(run.sizes)
+	for i, point := range run.points {
+		best, bestDist := 0, math.MaxFloat64
+		for j, centroid := range run.centroids {
+			if d := run.dist(point, centroid); d < bestDist {
+				best, bestDist = j, d
+			}
+		}
+		if run.assignment[i] != best {
+			run.assignment[i] = best
+			changed = true
+		}
+		run.sizes[best]++
+	}
+	return changed
+}
+
+// repairEmpty reseeds each empty cluster on the point furthest from its centroid
+// among clusters that can spare one. Clusters stay empty only when every point
+// already sits on its centroid.
+func (run *kMeansRun) repairEmpty() {
+	dists := make([]float64, len(run.points))
+	for i, point := range run.points {
+		dists[i] = run.dist(point, run.centroids[run.assignment[i]])
+	}
+	for cluster, size := range run.sizes {
+		if size > 0 {
+			continue
+		}
+		furthest := -1
+		for i, d := range dists {
+			if run.sizes[run.assignment[i]] > 1 && d > 0 && (furthest < 0 || d > dists[furthest]) {
+				furthest = i
+			}
+		}
+		if furthest < 0 {
+			return
+		}
+		run.sizes[run.assignment[furthest]]--
+		run.assignment[furthest] = cluster
+		run.sizes[cluster] = 1
+		run.centroids[cluster] = *run.points[furthest].PositionVectorToCentroid()
+		dists[furthest] = 0
+	}
+}
+
+// update moves each non-empty cluster's centroid to the mean of its points and
+// returns the furthest any centroid moved
+func (run *kMeansRun) update() float64 {
+	members := make([][]infra.PositionVector, len(run.centroids))
+	for i, point := range run.points {
+		members[run.assignment[i]] = append(members[run.assignment[i]], point)
+	}
+	shift := 0.0
+	for j, points := range members {
+		if len(points) == 0 {
+			continue
+		}
+		mean := run.topology.Mean(points)
+		shift = max(shift, infra.Distance(run.topology, run.centroids[j].X, run.centroids[j].Y, mean.X, mean.Y))
+		run.centroids[j] = mean
+	}
+	return shift
+}
+
+func (run *kMeansRun) inertia() float64 {
+	total := 0.0
+	for i, point := range run.points {
+		total += math.Pow(run.dist(point, run.centroids[run.assignment[i]]), 2)
+	}
+	return total
+}
+
+// kMeansRecord is the clustering applied this iteration, for the log
+func (tserv *TMTServer) kMeansRecord() *gameRecorder.JSONKMeansRecord {
+	if tserv.clustering.assignments == nil {
+		return nil
+	}
+	centroids := make([]gameRecorder.JSONCentroid, len(tserv.clustering.centroids))
+	for i, centroid := range tserv.clustering.centroids {
+		centroids[i] = gameRecorder.JSONCentroid{X: centroid.X, Y: centroid.Y}
+	}
+	return &gameRecorder.JSONKMeansRecord{
+		Centroids:  centroids,
+		Sizes:      tserv.clustering.sizes,
+		Inertia:    tserv.clustering.inertia,
+		Iterations: tserv.clustering.iterations,
+	}
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"github.com/aaashah/TMT_FYP/gameRecorder"
+	"github.com/aaashah/TMT_FYP/infra"
+	"github.com/google/uuid"
+)
+
+// lineageEntry outlives the agent, so the genealogy covers everyone who was ever born
+type lineageEntry struct {
+	agent          infra.IExtendedAgent
+	deathIteration int
+	deathCause     string
+}
+
+// registerLineage adds agents to the genealogy in the order given
+func (tserv *TMTServer) registerLineage(newAgents []infra.IExtendedAgent) {
+	for _, agent := range newAgents {
+		agentID := agent.GetID()
+		if _, seen := tserv.lineage[agentID]; seen {
+			continue
+		}
+		tserv.lineage[agentID] = &lineageEntry{agent: agent, deathIteration: -1}
+		tserv.lineageOrder = append(tserv.lineageOrder, agentID)
+	}
+}
+
+func (tserv *TMTServer) recordLineageDeaths(iter int, naturalReport, sacrificialReport map[uuid.UUID]infra.DeathInfo) {
+	for agentID := range naturalReport {
+		tserv.recordLineageDeath(agentID, iter, gameRecorder.DeathNatural)
+	}
+	for agentID, deathInfo := range sacrificialReport {
+		if deathInfo.WasVoluntary {
+			tserv.recordLineageDeath(agentID, iter, gameRecorder.DeathSelfSacrifice)
+		} else {
+			tserv.recordLineageDeath(agentID, iter, gameRecorder.DeathEliminated)
+		}
+	}
+}
+
+func (tserv *TMTServer) recordLineageDeath(agentID uuid.UUID, iter int, cause string) {
+	entry, ok := tserv.lineage[agentID]
+	if !ok {
+		return
+	}
+	entry.deathIteration = iter
+	entry.deathCause = cause
+}
+
+// GetLineageRecords returns every agent of the run, founders first and then children in birth order
+func (tserv *TMTServer) GetLineageRecords() []gameRecorder.LineageRecord {
+	records := make([]gameRecorder.LineageRecord, 0, len(tserv.lineageOrder))
+	for _, agentID := range tserv.lineageOrder {
+		entry := tserv.lineage[agentID]
+		lineage := entry.agent.GetLineage()
+		record := gameRecorder.LineageRecord{
+			ID:              agentID.String(),
+			AttachmentStyle: entry.agent.GetAttachment().Type.String(),
+			Generation:      lineage.Generation,
+			BirthIteration:  lineage.BirthIteration,
+			DeathIteration:  entry.deathIteration,
+			DeathCause:      entry.deathCause,
+		}
+		if !lineage.IsFounder() {
+			record.Parent1 = lineage.Parent1.String()
+			record.Parent2 = lineage.Parent2.String()
+		}
+		records = append(records, record)
+	}
+	return records
+}
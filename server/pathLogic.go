@@ -0,0 +1,38 @@
+package server
+
+import (
+	"github.com/aaashah/TMT_FYP/infra"
+)
+
+// plannedPath is the rest of an agent's route to target, starting from the cell after at
+type plannedPath struct {
+	target infra.PositionVector
+	at     infra.PositionVector
+	steps  []infra.PositionVector
+}
+
+// nextStepToward is the next cell on the agent's route to target, planning a new
+// route if the target has moved or the agent has left its route. If another agent
+// stands in the way, the agent steps greedily round it and replans next time.
+func (tserv *TMTServer) nextStepToward(agent infra.IExtendedAgent, target infra.PositionVector) (infra.PositionVector, bool) {
+	pos := agent.GetPosition()
+	path, ok := tserv.paths[agent.GetID()]
+	if !ok || path.target != target || path.at != pos {
+		path = &plannedPath{target: target, at: pos, steps: tserv.grid.FindPath(pos, target, tserv.pathOptions())}
+		tserv.paths[agent.GetID()] = path
+	}
+	if len(path.steps) == 0 {
+		return pos, false
+	}
+	next := path.steps[0]
+	if _, free := tserv.grid.CanEnter(next); !free {
+		delete(tserv.paths, agent.GetID())
+		return tserv.grid.StepToward(pos, target)
+	}
+	path.at, path.steps = next, path.steps[1:]
+	return next, true
+}
+
+func (tserv *TMTServer) pathOptions() infra.PathOptions {
+	return infra.PathOptions{MemorialCost: tserv.config.MemorialPathCost, SearchLimit: tserv.config.PathSearchLimit}
+}
@@ -1,8 +1,8 @@
 package server
 
 import (
-	"math/rand"
-	"time"
+	"bytes"
+	"slices"
 
 	"github.com/aaashah/TMT_FYP/agents"
 	"github.com/aaashah/TMT_FYP/infra"
@@ -11,9 +11,9 @@ import (
 )
 
 func (tserv *TMTServer) updateAgentMortality() {
-	for _, agent := range tserv.GetAgentMap() {
+	for _, agent := range tserv.sortedAgents() {
 		probDeath := agent.GetTelomere()
-		randVal := rand.Float64()
+		randVal := tserv.rng.Float64()
 		if randVal < probDeath {
 			// fmt.Printf("Agent age: %d, Death prob: %f\n", agent.GetAge(), probDeath)
 			agent.MarkAsDead()
@@ -21,17 +21,19 @@ func (tserv *TMTServer) updateAgentMortality() {
 	}
 }
 
-func (tserv *TMTServer) voluntarilySacrificeAgent(agent infra.IExtendedAgent) {
+func (tserv *TMTServer) voluntarilySacrificeAgent(agent infra.IExtendedAgent, iter int) {
 	pos := agent.GetPosition()
-	tserv.grid.PlaceTemple(pos.X, pos.Y)
+	tserv.grid.RemoveAgent(agent)
+	tserv.grid.PlaceTemple(pos.X, pos.Y, iter)
 	tserv.lastEliminatedAgents = append(tserv.lastEliminatedAgents, agent)
 	tserv.lastSelfSacrificedAgents = append(tserv.lastSelfSacrificedAgents, agent)
 	// fmt.Printf("Agent %v has been eliminated (voluntary)\n", agent.GetID())
 }
 
-func (tserv *TMTServer) involuntarilySacrificeAgent(agent infra.IExtendedAgent) {
+func (tserv *TMTServer) involuntarilySacrificeAgent(agent infra.IExtendedAgent, iter int) {
 	pos := agent.GetPosition()
-	tserv.grid.PlaceTombstone(pos.X, pos.Y)
+	tserv.grid.RemoveAgent(agent)
+	tserv.grid.PlaceTombstone(pos.X, pos.Y, iter)
 	tserv.lastEliminatedAgents = append(tserv.lastEliminatedAgents, agent)
 	// fmt.Printf("Agent %v has been eliminated (non-voluntary)\n", agent.GetID())
 }
@@ -46,74 +48,91 @@ func (tserv *TMTServer) getNaturalEliminationReport() map[uuid.UUID]infra.DeathI
 	return naturalReport
 }
 
-func (tserv *TMTServer) stratifyVolunteers() ([]infra.IExtendedAgent, []infra.IExtendedAgent) {
+// stratifyVolunteers asks every living agent for its ASM decision and splits them into
+// volunteers, abstainers (INACTION) and refusers
+func (tserv *TMTServer) stratifyVolunteers() ([]infra.IExtendedAgent, []infra.IExtendedAgent, []infra.IExtendedAgent) {
 	volunteers := make([]infra.IExtendedAgent, 0)
-	nonVolunteers := make([]infra.IExtendedAgent, 0)
-
-	// Separate volunteers and non-volunteers
-	for _, agent := range tserv.GetAgentMap() {
-		// don't allow naturally-dead agents to sacrifice
-		if !agent.IsAlive() {
-			continue
+	abstainers := make([]infra.IExtendedAgent, 0)
+	refusers := make([]infra.IExtendedAgent, 0)
+
+	// don't allow naturally-dead agents to sacrifice
+	living := slices.DeleteFunc(tserv.sortedAgents(), func(agent infra.IExtendedAgent) bool { return !agent.IsAlive() })
+	for i, decision := range tserv.decideAll(living) {
+		agent := living[i]
+		switch decision {
+		case infra.SELF_SACRIFICE:
+			volunteers = append(volunteers, agent)
+		case infra.INACTION:
+			abstainers = append(abstainers, agent)
+		default:
+			refusers = append(refusers, agent)
 		}
-		// check if agent volunteered
-		if agent.GetASMDecision(tserv.grid) == infra.SELF_SACRIFICE {
+	}
+	return volunteers, abstainers, refusers
+}
+
+// reconsiderAbstainers asks each abstainer for a second decision. Only the stochastic
+// decision rules can change their answer.
+func (tserv *TMTServer) reconsiderAbstainers(volunteers, abstainers, refusers []infra.IExtendedAgent) ([]infra.IExtendedAgent, []infra.IExtendedAgent, []infra.IExtendedAgent) {
+	stillAbstaining := make([]infra.IExtendedAgent, 0)
+	for i, decision := range tserv.decideAll(abstainers) {
+		agent := abstainers[i]
+		switch decision {
+		case infra.SELF_SACRIFICE:
 			volunteers = append(volunteers, agent)
-		} else {
-			nonVolunteers = append(nonVolunteers, agent)
+		case infra.INACTION:
+			stillAbstaining = append(stillAbstaining, agent)
+		default:
+			refusers = append(refusers, agent)
 		}
 	}
-	return volunteers, nonVolunteers
+	return volunteers, stillAbstaining, refusers
+}
+
+// nonVolunteerTiers groups the agents who did not volunteer into the tiers an elimination
+// policy draws from in order, each tier in ID order
+func (tserv *TMTServer) nonVolunteerTiers(abstainers, refusers []infra.IExtendedAgent) [][]infra.IExtendedAgent {
+	if tserv.config.InactionPolicy == "first" {
+		// abstainers are drawn before any refuser
+		return [][]infra.IExtendedAgent{abstainers, refusers}
+	}
+
+	// otherwise abstainers and refusers are drawn alike
+	nonVolunteers := slices.Concat(abstainers, refusers)
+	slices.SortFunc(nonVolunteers, func(a, b infra.IExtendedAgent) int {
+		idA, idB := a.GetID(), b.GetID()
+		return bytes.Compare(idA[:], idB[:])
+	})
+	return [][]infra.IExtendedAgent{nonVolunteers}
 }
 
 func (tserv *TMTServer) getSacrificialEliminationReport() map[uuid.UUID]infra.DeathInfo {
-	volunteers, nonVolunteers := tserv.stratifyVolunteers()
-	sacrificialReport := make(map[uuid.UUID]infra.DeathInfo)
+	volunteers, abstainers, refusers := tserv.stratifyVolunteers()
+	if tserv.config.InactionPolicy == "reconsider" {
+		volunteers, abstainers, refusers = tserv.reconsiderAbstainers(volunteers, abstainers, refusers)
+	}
 
 	totalAgents := float64(len(tserv.GetAgentMap()))
-	neededVolunteers := int(tserv.config.PopulationRho * totalAgents)
-	actualVolunteers := len(volunteers)
+	neededVolunteers := int(tserv.populationRho * totalAgents)
 	// record number of volunteers
-	tserv.numVolunteeredAgents = actualVolunteers
-
-	// fmt.Println(totalAgents, neededVolunteers, actualVolunteers, tserv.expectedChildren)
-
-	if actualVolunteers >= neededVolunteers {
-		//randomly select n volunteers to eliminate
-		rand.Shuffle(actualVolunteers, func(i, j int) {
-			volunteers[i], volunteers[j] = volunteers[j], volunteers[i]
-		})
-		for i := range neededVolunteers {
-			agent := volunteers[i]
-			agentID := agent.GetID()
-			sacrificialReport[agentID] = infra.DeathInfo{Agent: agent, WasVoluntary: true}
-		}
-	} else {
-		//eliminate all volunteers...
-		for _, agent := range volunteers {
-			agentID := agent.GetID()
-			sacrificialReport[agentID] = infra.DeathInfo{Agent: agent, WasVoluntary: true}
-		}
-		// ...plus 2*(n-v) random non-volunteers
-		numNonVol := len(nonVolunteers)
-		rand.Shuffle(numNonVol, func(i, j int) {
-			nonVolunteers[i], nonVolunteers[j] = nonVolunteers[j], nonVolunteers[i]
-		})
-
-		for i := range min(numNonVol, 2*(neededVolunteers-actualVolunteers)) {
-			agent := nonVolunteers[i]
-			agentID := agent.GetID()
-			sacrificialReport[agentID] = infra.DeathInfo{Agent: agent, WasVoluntary: false}
-		}
+	tserv.numVolunteeredAgents = len(volunteers)
+	tserv.numAbstainedAgents = len(abstainers)
+	tserv.numRefusedAgents = len(refusers)
+	tserv.numRequiredSacrifices = neededVolunteers
+
+	nonVolunteers := tserv.nonVolunteerTiers(abstainers, refusers)
+	if tserv.config.ClusterQuotas {
+		return tserv.selectClusterEliminations(volunteers, nonVolunteers)
 	}
-
-	return sacrificialReport
+	return tserv.eliminationPolicy.SelectEliminations(tserv, volunteers, nonVolunteers, neededVolunteers)
 }
 
 func (tserv *TMTServer) updateAgentYsterofimia(deathReport map[uuid.UUID]infra.DeathInfo) {
 	for _, agent := range tserv.GetAgentMap() {
 		networkEliminationCount := 0
-		for friendID, esteem := range agent.GetNetwork() {
+		network := agent.GetNetwork()
+		for _, friendID := range infra.SortedIDs(network) {
+			esteem := network[friendID]
 			// friend was eliminated (found in death report)
 			if deathInfo, dead := deathReport[friendID]; dead {
 				networkEliminationCount++
@@ -166,50 +185,83 @@ func (tserv *TMTServer) applyElimination(deathReport map[uuid.UUID]infra.DeathIn
 	}
 }
 
-func (tserv *TMTServer) performSacrifices(deathReport map[uuid.UUID]infra.DeathInfo) {
+func (tserv *TMTServer) performSacrifices(deathReport map[uuid.UUID]infra.DeathInfo, iter int) {
 	tserv.lastEliminatedAgents = nil
 	tserv.lastSelfSacrificedAgents = nil
 
-	for _, deathInfo := range deathReport {
+	for _, deadID := range infra.SortedIDs(deathReport) {
+		deathInfo := deathReport[deadID]
 		deadAgent := deathInfo.Agent
 		if deathInfo.WasVoluntary {
-			tserv.voluntarilySacrificeAgent(deadAgent)
+			tserv.voluntarilySacrificeAgent(deadAgent, iter)
 		} else {
-			tserv.involuntarilySacrificeAgent(deadAgent)
+			tserv.involuntarilySacrificeAgent(deadAgent, iter)
 		}
 	}
 }
 
-func (tserv *TMTServer) generateNewAgents() []infra.IExtendedAgent {
+// CreateInitialPopulation adds NumAgents agents split by the configured attachment proportions
+func (tserv *TMTServer) CreateInitialPopulation() []infra.IExtendedAgent {
+	agentPopulation := make([]infra.IExtendedAgent, 0)
+
+	totalAgents := float64(tserv.config.NumAgents)
+	for range int(totalAgents * tserv.config.DismissiveProp) {
+		agentPopulation = append(agentPopulation, agents.CreateDismissiveAgent(tserv))
+	}
+	for range int(totalAgents * tserv.config.FearfulProp) {
+		agentPopulation = append(agentPopulation, agents.CreateFearfulAgent(tserv))
+	}
+	for range int(totalAgents * tserv.config.PreoccupiedProp) {
+		agentPopulation = append(agentPopulation, agents.CreatePreoccupiedAgent(tserv))
+	}
+	for range int(totalAgents * tserv.config.SecureProp) {
+		agentPopulation = append(agentPopulation, agents.CreateSecureAgent(tserv))
+	}
+
+	for _, agent := range agentPopulation {
+		// validation keeps NumAgents within the grid, so there is always a free cell
+		tserv.placeAgent(agent)
+		tserv.AddAgent(agent)
+	}
+	return agentPopulation
+}
+
+func (tserv *TMTServer) generateNewAgents(iter int) []infra.IExtendedAgent {
+	spacesAvailable := max(3*tserv.config.NumAgents-len(tserv.GetAgentMap()), 0)
+	if tserv.config.ClusterQuotas {
+		return tserv.generateClusterAgents(iter, &spacesAvailable)
+	}
+	return tserv.breedFromPool(tserv.lastEliminatedAgents, tserv.expectedChildren, iter, &spacesAvailable)
+}
+
+// breedFromPool pairs up parentPool at random, each pair having Poisson(expectedChildren) children
+func (tserv *TMTServer) breedFromPool(parentPool []infra.IExtendedAgent, expectedChildren float64, iter int, spacesAvailable *int) []infra.IExtendedAgent {
 	newAgents := make([]infra.IExtendedAgent, 0)
 
 	dist := distuv.Poisson{
-		Lambda: tserv.expectedChildren,
-		Src:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		Lambda: expectedChildren,
+		Src:    tserv.rng,
 	}
 
-	parentPool := tserv.lastEliminatedAgents
 	poolSize := len(parentPool)
 
-	rand.Shuffle(poolSize, func(i, j int) {
+	tserv.rng.Shuffle(poolSize, func(i, j int) {
 		parentPool[i], parentPool[j] = parentPool[j], parentPool[i]
 	})
 
-	spacesAvailable := max(3*tserv.config.NumAgents-len(tserv.GetAgentMap()), 0)
-
 	for i := 1; i < poolSize; i += 2 {
 		parent1 := parentPool[i-1]
 		parent2 := parentPool[i]
 		childrenToSpawn := int(dist.Rand())
-		for range min(spacesAvailable, childrenToSpawn) {
-			newAgents = append(newAgents, tserv.generateChild(parent1, parent2))
+		for range min(*spacesAvailable, childrenToSpawn) {
+			newAgents = append(newAgents, tserv.generateChild(parent1, parent2, iter))
 		}
-		spacesAvailable -= childrenToSpawn
+		*spacesAvailable -= childrenToSpawn
 	}
 
 	if poolSize%2 == 1 && poolSize > 1 {
 		clonerAgent := parentPool[poolSize-1]
-		newAgents = append(newAgents, tserv.generateChild(clonerAgent, clonerAgent))
+		newAgents = append(newAgents, tserv.generateChild(clonerAgent, clonerAgent, iter))
 	}
 
 	return newAgents
@@ -244,11 +296,11 @@ func (tserv *TMTServer) getChildProbabilities(parent1, parent2 infra.AttachmentT
 func (tserv *TMTServer) mixAttachmentTypes(parent1, parent2 infra.AttachmentType) infra.AttachmentType {
 	probMap := tserv.getChildProbabilities(parent1, parent2)
 
-	randVal := rand.Float64()
+	randVal := tserv.rng.Float64()
 	cumulative := 0.0
 
-	for attachType, prob := range probMap {
-		cumulative += prob
+	for _, attachType := range infra.AllAttachmentTypes {
+		cumulative += probMap[attachType]
 		if randVal < cumulative {
 			return attachType
 		}
@@ -258,25 +310,32 @@ func (tserv *TMTServer) mixAttachmentTypes(parent1, parent2 infra.AttachmentType
 
 }
 
-func (tserv *TMTServer) generateChild(parent1, parent2 infra.IExtendedAgent) infra.IExtendedAgent {
+func (tserv *TMTServer) createAgentOfType(attachType infra.AttachmentType) infra.IExtendedAgent {
+	switch attachType {
+	case infra.SECURE:
+		return agents.CreateSecureAgent(tserv)
+	case infra.DISMISSIVE:
+		return agents.CreateDismissiveAgent(tserv)
+	case infra.PREOCCUPIED:
+		return agents.CreatePreoccupiedAgent(tserv)
+	default:
+		return agents.CreateFearfulAgent(tserv)
+	}
+}
+
+func (tserv *TMTServer) generateChild(parent1, parent2 infra.IExtendedAgent, iter int) infra.IExtendedAgent {
 	type1 := parent1.GetAttachment().Type
 	type2 := parent2.GetAttachment().Type
 	childAttachmentType := tserv.mixAttachmentTypes(type1, type2)
 	// childWorldview := tserv.mixWorldviews(parent1.GetWorldviewBinary(), parent2.GetWorldviewBinary())
 
-	var newAgent infra.IExtendedAgent
-	switch {
-	case childAttachmentType == infra.SECURE:
-		newAgent = agents.CreateSecureAgent(tserv)
-	case childAttachmentType == infra.DISMISSIVE:
-		newAgent = agents.CreateDismissiveAgent(tserv)
-	case childAttachmentType == infra.PREOCCUPIED:
-		newAgent = agents.CreatePreoccupiedAgent(tserv)
-	case childAttachmentType == infra.FEARFUL:
-		newAgent = agents.CreateFearfulAgent(tserv)
-	default:
-		newAgent = agents.CreateFearfulAgent(tserv)
-	}
+	newAgent := tserv.createAgentOfType(childAttachmentType)
+	newAgent.SetLineage(infra.Lineage{
+		Parent1:        parent1.GetID(),
+		Parent2:        parent2.GetID(),
+		Generation:     max(parent1.GetLineage().Generation, parent2.GetLineage().Generation) + 1,
+		BirthIteration: iter,
+	})
 
 	return newAgent
 
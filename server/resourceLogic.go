@@ -0,0 +1,79 @@
+package server
+
+import (
+	"github.com/aaashah/TMT_FYP/gameRecorder"
+	"github.com/aaashah/TMT_FYP/infra"
+	"github.com/google/uuid"
+)
+
+// forageStep picks the free neighbouring cell with the most food, breaking ties
+// at random, and reports false if the agent is boxed in
+func (tserv *TMTServer) forageStep(pos infra.PositionVector) (infra.PositionVector, bool) {
+	richest := make([]infra.PositionVector, 0)
+	mostFood := -1.0
+	for _, next := range tserv.grid.FreeNeighbours(pos) {
+		food := tserv.grid.Food.At(next)
+		if food > mostFood {
+			richest, mostFood = []infra.PositionVector{next}, food
+		} else if food == mostFood {
+			richest = append(richest, next)
+		}
+	}
+	if len(richest) == 0 {
+		return pos, false
+	}
+	return richest[tserv.rng.IntN(len(richest))], true
+}
+
+// feed lets an agent eat what it can from its cell, then burns a turn's energy
+func (tserv *TMTServer) feed(agent infra.IExtendedAgent) {
+	eaten := tserv.grid.Food.Harvest(agent.GetPosition(), tserv.config.MaxEnergy-agent.GetEnergy())
+	agent.AddEnergy(eaten - tserv.config.Metabolism)
+}
+
+// applyStarvation kills every agent that has run out of energy
+func (tserv *TMTServer) applyStarvation(iter int) map[uuid.UUID]infra.DeathInfo {
+	report := make(map[uuid.UUID]infra.DeathInfo)
+	tserv.starvedAgents = nil
+	if tserv.grid.Food == nil {
+		return report
+	}
+	for _, agent := range tserv.sortedAgents() {
+		if agent.GetEnergy() > 0 {
+			continue
+		}
+		agent.MarkAsDead()
+		tserv.RemoveAgent(agent)
+		tserv.starvedAgents = append(tserv.starvedAgents, agent)
+		report[agent.GetID()] = infra.DeathInfo{Agent: agent, WasVoluntary: false}
+		tserv.recordLineageDeath(agent.GetID(), iter, gameRecorder.DeathStarvation)
+	}
+	return report
+}
+
+// scarcity is the share of the population's food needs for an iteration that
+// the grid can't currently meet, between 0 and 1
+func (tserv *TMTServer) scarcity() float64 {
+	need := float64(len(tserv.GetAgentMap())) * tserv.config.Metabolism * float64(tserv.config.NumTurns)
+	if need == 0 {
+		return 0
+	}
+	return max(0, 1-tserv.grid.Food.Total()/need)
+}
+
+func (tserv *TMTServer) resourceRecord() *gameRecorder.JSONResourceRecord {
+	if tserv.grid.Food == nil {
+		return nil
+	}
+	agents := tserv.sortedAgents()
+	meanEnergy := 0.0
+	for _, agent := range agents {
+		meanEnergy += agent.GetEnergy() / float64(len(agents))
+	}
+	return &gameRecorder.JSONResourceRecord{
+		TotalFood:  tserv.grid.Food.Total(),
+		MeanEnergy: meanEnergy,
+		Scarcity:   tserv.scarcity(),
+		Starved:    agentsToStrings(tserv.starvedAgents),
+	}
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"maps"
+
+	"github.com/aaashah/TMT_FYP/infra"
+)
+
+// RunSummary holds the headline outcomes of a run, kept up to date at the end of each iteration
+type RunSummary struct {
+	InitialPopulation     int
+	FinalPopulation       int
+	IterationsRun         int
+	ExtinctionIteration   int // first iteration ending with no agents, -1 if the population survived
+	TotalVolunteers       int
+	TotalRequired         int
+	FinalAttachmentCounts map[infra.AttachmentType]int
+}
+
+func newRunSummary() *RunSummary {
+	return &RunSummary{
+		ExtinctionIteration:   -1,
+		FinalAttachmentCounts: make(map[infra.AttachmentType]int),
+	}
+}
+
+// MeanVolunteers is the average number of volunteers per iteration
+func (rs *RunSummary) MeanVolunteers() float64 {
+	if rs.IterationsRun == 0 {
+		return 0
+	}
+	return float64(rs.TotalVolunteers) / float64(rs.IterationsRun)
+}
+
+// MeanRequired is the average number of required sacrifices per iteration
+func (rs *RunSummary) MeanRequired() float64 {
+	if rs.IterationsRun == 0 {
+		return 0
+	}
+	return float64(rs.TotalRequired) / float64(rs.IterationsRun)
+}
+
+// PopulationRatio is the final population relative to the initial population
+func (rs *RunSummary) PopulationRatio() float64 {
+	if rs.InitialPopulation == 0 {
+		return 0
+	}
+	return float64(rs.FinalPopulation) / float64(rs.InitialPopulation)
+}
+
+func (tserv *TMTServer) updateRunSummary(iter int) {
+	summary := tserv.summary
+	agentMap := tserv.GetAgentMap()
+
+	summary.IterationsRun = iter + 1
+	summary.FinalPopulation = len(agentMap)
+	summary.TotalVolunteers += tserv.numVolunteeredAgents
+	summary.TotalRequired += tserv.numRequiredSacrifices
+	if len(agentMap) == 0 && summary.ExtinctionIteration < 0 {
+		summary.ExtinctionIteration = iter
+	}
+
+	clear(summary.FinalAttachmentCounts)
+	for _, agent := range agentMap {
+		summary.FinalAttachmentCounts[agent.GetAttachment().Type]++
+	}
+}
+
+// GetRunSummary returns the outcomes of the run so far
+func (tserv *TMTServer) GetRunSummary() RunSummary {
+	summary := *tserv.summary
+	summary.FinalAttachmentCounts = maps.Clone(tserv.summary.FinalAttachmentCounts)
+	return summary
+}
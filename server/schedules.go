@@ -0,0 +1,121 @@
+package server
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+
+	"github.com/aaashah/TMT_FYP/config"
+)
+
+// ParameterSchedule gives the value of rho or tau in each iteration
+type ParameterSchedule interface {
+	At(iter int) float64
+}
+
+// NewParameterSchedule builds the schedule described by s, or a constant one
+// holding base if there is no schedule
+func NewParameterSchedule(s *config.Schedule, base float64) (ParameterSchedule, error) {
+	if s == nil {
+		return ConstantSchedule(base), nil
+	}
+	switch s.Type {
+	case "linear":
+		return LinearSchedule{Start: s.StartIteration, End: s.EndIteration, From: s.From, To: s.To}, nil
+	case "step":
+		return StepSchedule{Initial: base, Steps: s.Steps}, nil
+	case "sine":
+		return SineSchedule{Mean: s.Mean, Amplitude: s.Amplitude, Period: s.Period, Phase: s.Phase}, nil
+	case "csv":
+		steps, err := readScheduleCSV(s.Path)
+		if err != nil {
+			return nil, err
+		}
+		return StepSchedule{Initial: base, Steps: steps}, nil
+	default:
+		return nil, fmt.Errorf("unknown schedule type %q", s.Type)
+	}
+}
+
+type ConstantSchedule float64
+
+func (c ConstantSchedule) At(iter int) float64 {
+	return float64(c)
+}
+
+// LinearSchedule ramps from From at iteration Start to To at iteration End
+type LinearSchedule struct {
+	Start, End int
+	From, To   float64
+}
+
+func (l LinearSchedule) At(iter int) float64 {
+	if iter <= l.Start {
+		return l.From
+	}
+	if iter >= l.End {
+		return l.To
+	}
+	progress := float64(iter-l.Start) / float64(l.End-l.Start)
+	return l.From + progress*(l.To-l.From)
+}
+
+// StepSchedule holds each step's value until the next step, and Initial before the first
+type StepSchedule struct {
+	Initial float64
+	Steps   []config.ScheduleStep
+}
+
+func (s StepSchedule) At(iter int) float64 {
+	value := s.Initial
+	for _, step := range s.Steps {
+		if step.Iteration > iter {
+			break
+		}
+		value = step.Value
+	}
+	return value
+}
+
+// SineSchedule models seasons: Mean + Amplitude * sin(2*pi*(iter + Phase) / Period)
+type SineSchedule struct {
+	Mean, Amplitude, Period, Phase float64
+}
+
+func (s SineSchedule) At(iter int) float64 {
+	return s.Mean + s.Amplitude*math.Sin(2*math.Pi*(float64(iter)+s.Phase)/s.Period)
+}
+
+// readScheduleCSV reads iteration,value rows. A header row is allowed.
+func readScheduleCSV(path string) ([]config.ScheduleStep, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open schedule: %w", err)
+	}
+	defer file.Close()
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = 2
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %s: %w", path, err)
+	}
+
+	steps := make([]config.ScheduleStep, 0, len(rows))
+	for i, row := range rows {
+		iter, iterErr := strconv.Atoi(row[0])
+		value, valueErr := strconv.ParseFloat(row[1], 64)
+		if iterErr != nil || valueErr != nil {
+			if i == 0 {
+				continue // header
+			}
+			return nil, fmt.Errorf("invalid schedule %s: row %d is not iteration,value", path, i+1)
+		}
+		steps = append(steps, config.ScheduleStep{Iteration: iter, Value: value})
+	}
+	if err := config.ValidateSteps(steps); err != nil {
+		return nil, fmt.Errorf("invalid schedule %s: %w", path, err)
+	}
+	return steps, nil
+}
@@ -1,6 +1,8 @@
 package server
 
 import (
+	"github.com/aaashah/TMT_FYP/gameRecorder"
+	"github.com/aaashah/TMT_FYP/infra"
 	"github.com/google/uuid"
 )
 
@@ -37,3 +39,77 @@ func (serv *TMTServer) SeverNetworkConnection(fromAgentID, toAgentID uuid.UUID)
 func (serv *TMTServer) SubmitDecisionThreshold(agentID uuid.UUID, score float64) {
 	serv.agentDecisionThresholds[agentID] = score
 }
+
+func (serv *TMTServer) SubmitASMDecision(agentID uuid.UUID, scores infra.ASMScores, decision infra.ASMDecison) {
+	weights := serv.config.ASMWeights
+	if agent, ok := serv.GetAgentByID(agentID); ok {
+		weights = serv.GetASMWeights(agent.GetAttachment().Type)
+	}
+	ms := scores.MortalitySalience
+	wv := scores.WorldviewValidation
+	rv := scores.RelationshipValidation
+	serv.agentDecisions[agentID] = gameRecorder.JSONDecisionRecord{
+		MortalitySalience: gameRecorder.JSONMortalitySalience{
+			Score: ms.Score(weights.MortalitySalience),
+			CE:    ms.ClusterEliminations,
+			NE:    ms.NetworkEliminations,
+			RA:    ms.RelativeAge,
+			MP:    ms.MemorialProximity,
+		},
+		WorldviewValidation: gameRecorder.JSONWorldviewValidation{
+			Score:       wv.Score(weights.WorldviewValidation),
+			CPR:         wv.ClusterProfile,
+			NPR:         wv.NetworkProfile,
+			Ysterofimia: wv.Ysterofimia,
+		},
+		RelationshipValidation: gameRecorder.JSONRelationshipValidation{
+			Score:           rv.Score(weights.RelationshipValidation),
+			EST:             rv.Estrangement,
+			PSE:             rv.ProSocialEsteem,
+			HeroismTendency: rv.HeroismTendency,
+		},
+		ASMDecision: decision.String(),
+	}
+}
+
+// GetNetworkSnapshot captures every agent's social network, with nodes and edges in ID order
+func (serv *TMTServer) GetNetworkSnapshot(iter int) gameRecorder.NetworkSnapshot {
+	agentMap := serv.GetAgentMap()
+	snapshot := gameRecorder.NetworkSnapshot{
+		Iteration: iter,
+		Nodes:     make([]gameRecorder.NetworkNode, 0, len(agentMap)),
+		Edges:     make([]gameRecorder.NetworkEdge, 0),
+	}
+	for _, agent := range serv.sortedAgents() {
+		snapshot.Nodes = append(snapshot.Nodes, gameRecorder.NetworkNode{
+			ID:              agent.GetID().String(),
+			AttachmentStyle: agent.GetAttachment().Type.String(),
+			ClusterID:       agent.GetClusterID(),
+			Age:             agent.GetAge(),
+			Heroism:         agent.GetHeroism(),
+		})
+		network := agent.GetNetwork()
+		for _, friendID := range infra.SortedIDs(network) {
+			// ties to agents that have left the simulation would be dangling edges
+			if _, alive := agentMap[friendID]; !alive {
+				continue
+			}
+			snapshot.Edges = append(snapshot.Edges, gameRecorder.NetworkEdge{
+				Source: agent.GetID().String(),
+				Target: friendID.String(),
+				Weight: network[friendID],
+			})
+		}
+	}
+	return snapshot
+}
+
+func (serv *TMTServer) recordNetworkSnapshot(iter int) {
+	every := serv.config.NetworkSnapshotEvery
+	if serv.runDir == "" || every <= 0 || iter%every != 0 {
+		return
+	}
+	if err := gameRecorder.WriteNetworkSnapshot(serv.runDir, serv.GetNetworkSnapshot(iter)); err != nil {
+		panic(err)
+	}
+}
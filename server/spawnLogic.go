@@ -0,0 +1,102 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/aaashah/TMT_FYP/infra"
+)
+
+// placeAgents puts each new agent on a free cell chosen by the spawn strategy and
+// returns those that found one. Agents left over once the grid is full are never born.
+func (tserv *TMTServer) placeAgents(newAgents []infra.IExtendedAgent) []infra.IExtendedAgent {
+	placed := make([]infra.IExtendedAgent, 0, len(newAgents))
+	for _, agent := range newAgents {
+		if !tserv.placeAgent(agent) {
+			delete(tserv.inheritedR0, agent.GetID())
+			continue
+		}
+		placed = append(placed, agent)
+	}
+	return placed
+}
+
+// placeAgent moves an agent onto a free cell and registers it on the grid,
+// reporting false if there is no free cell
+func (tserv *TMTServer) placeAgent(agent infra.IExtendedAgent) bool {
+	var pos infra.PositionVector
+	var ok bool
+	if anchor, anchored := tserv.spawnAnchor(agent); anchored {
+		pos, ok = tserv.grid.FreeCellNear(anchor, tserv.config.SpawnRadius)
+	} else {
+		pos, ok = tserv.grid.RandomFreeCell()
+	}
+	if !ok {
+		return false
+	}
+	agent.SetPosition(pos)
+	tserv.grid.PlaceAgent(agent, pos)
+	return true
+}
+
+// spawnAnchor is the point an agent should be placed near. Founders and immigrants
+// have no parent, so under the parent and centroid strategies they go anywhere.
+func (tserv *TMTServer) spawnAnchor(agent infra.IExtendedAgent) (infra.Centroid, bool) {
+	switch tserv.config.SpawnStrategy {
+	case "zone":
+		return tserv.spawnZoneCentre(), true
+	case "parent":
+		if parent, ok := tserv.firstParent(agent); ok {
+			return *parent.GetPosition().PositionVectorToCentroid(), true
+		}
+	case "centroid":
+		if parent, ok := tserv.firstParent(agent); ok {
+			return tserv.clusterCentre(parent), true
+		}
+	}
+	return infra.Centroid{}, false
+}
+
+func (tserv *TMTServer) firstParent(agent infra.IExtendedAgent) (infra.IExtendedAgent, bool) {
+	lineage := agent.GetLineage()
+	if lineage.Generation == 0 {
+		return nil, false
+	}
+	entry, ok := tserv.lineage[lineage.Parent1]
+	if !ok {
+		return nil, false
+	}
+	return entry.agent, true
+}
+
+// clusterCentre is the centre of the living members of the parent's last cluster,
+// or where the parent died if none of them are left
+func (tserv *TMTServer) clusterCentre(parent infra.IExtendedAgent) infra.Centroid {
+	members := make([]infra.PositionVector, 0)
+	for _, memberID := range tserv.clusterMap[parent.GetClusterID()] {
+		if member, alive := tserv.GetAgentByID(memberID); alive {
+			members = append(members, member.GetPosition())
+		}
+	}
+	if len(members) == 0 {
+		return *parent.GetPosition().PositionVectorToCentroid()
+	}
+	return tserv.grid.Topology.Mean(members)
+}
+
+func (tserv *TMTServer) spawnZoneCentre() infra.Centroid {
+	centre := infra.Centroid{X: float64(tserv.config.SpawnZoneX), Y: float64(tserv.config.SpawnZoneY)}
+	if tserv.config.SpawnZoneX < 0 {
+		centre.X = float64(tserv.config.GridWidth / 2)
+	}
+	if tserv.config.SpawnZoneY < 0 {
+		centre.Y = float64(tserv.config.GridHeight / 2)
+	}
+	return centre
+}
+
+// checkGrid panics if the grid and the agents disagree about where anyone is
+func (tserv *TMTServer) checkGrid(when string) {
+	if err := tserv.grid.CheckConsistency(tserv.sortedAgents()); err != nil {
+		panic(fmt.Sprintf("grid inconsistent %s: %v", when, err))
+	}
+}
@@ -0,0 +1,122 @@
+package sweep
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/aaashah/TMT_FYP/config"
+	"github.com/aaashah/TMT_FYP/infra"
+)
+
+// Main runs the sweep subcommand, e.g.
+//
+//	tmtSimulator sweep -param rho=0:1:0.1 -param tau=0.3,0.5 -replicates 20 -out sweep.csv
+func Main(args []string) {
+	fs := flag.NewFlagSet("sweep", flag.ExitOnError)
+	params := make([]Parameter, 0)
+	fs.Func("param", "Swept parameter as name=v1,v2,... or name=start:stop:step (repeatable)", func(spec string) error {
+		param, err := ParseParameter(spec)
+		if err != nil {
+			return err
+		}
+		params = append(params, param)
+		return nil
+	})
+	scenarioPath := fs.String("config", "", "Path to a JSON scenario file for the unswept parameters")
+	replicates := fs.Int("replicates", 1, "Number of runs per parameter combination")
+	workers := fs.Int("workers", 0, "Number of concurrent runs (0 uses every CPU)")
+	outputPath := fs.String("out", "sweeps/results.csv", "Path of the results table")
+	fs.Parse(args)
+
+	base := config.DefaultConfig()
+	if *scenarioPath != "" {
+		var err error
+		base, err = config.ReadScenarioFile(*scenarioPath)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	sweep := Sweep{
+		Base:       base,
+		Parameters: params,
+		Replicates: *replicates,
+		Workers:    *workers,
+	}
+
+	start := time.Now()
+	results, err := sweep.Execute(func(completed, total int) {
+		fmt.Printf("\rCompleted %d/%d runs", completed, total)
+	})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("\nSweep finished in %v\n", time.Since(start).Round(time.Millisecond))
+
+	if err := WriteResultsCSV(*outputPath, params, results); err != nil {
+		panic(err)
+	}
+	fmt.Printf("Results written to %s\n", *outputPath)
+}
+
+// WriteResultsCSV writes one row per run: its swept values followed by the run's summary
+func WriteResultsCSV(path string, params []Parameter, results []Result) error {
+	err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create results file: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+
+	header := []string{"Run", "Replicate", "Seed"}
+	for _, param := range params {
+		header = append(header, param.Name)
+	}
+	header = append(header, "InitialPopulation", "FinalPopulation", "PopulationRatio", "IterationsRun",
+		"ExtinctionIteration", "MeanVolunteers", "MeanRequired")
+	for _, attachType := range infra.AllAttachmentTypes {
+		header = append(header, attachType.String())
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	formatFloat := func(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) }
+	for _, result := range results {
+		summary := result.Summary
+		row := []string{
+			strconv.Itoa(result.Index),
+			strconv.Itoa(result.Replicate),
+			strconv.FormatInt(result.Config.Seed, 10),
+		}
+		row = append(row, result.Values...)
+		row = append(row,
+			strconv.Itoa(summary.InitialPopulation),
+			strconv.Itoa(summary.FinalPopulation),
+			formatFloat(summary.PopulationRatio()),
+			strconv.Itoa(summary.IterationsRun),
+			strconv.Itoa(summary.ExtinctionIteration),
+			formatFloat(summary.MeanVolunteers()),
+			formatFloat(summary.MeanRequired()),
+		)
+		for _, attachType := range infra.AllAttachmentTypes {
+			row = append(row, strconv.Itoa(summary.FinalAttachmentCounts[attachType]))
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
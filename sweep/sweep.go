@@ -0,0 +1,176 @@
+package sweep
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aaashah/TMT_FYP/config"
+	"github.com/aaashah/TMT_FYP/server"
+)
+
+// Parameter is one swept config field, named by its command-line flag (e.g. "rho")
+type Parameter struct {
+	Name   string
+	Values []string
+}
+
+// Sweep runs every combination of parameter values, Replicates times each
+type Sweep struct {
+	Base       config.Config
+	Parameters []Parameter
+	Replicates int
+	Workers    int
+}
+
+// Run is a single simulation within a sweep
+type Run struct {
+	Index     int
+	Replicate int
+	Values    []string // one value per swept parameter, in Sweep.Parameters order
+	Config    config.Config
+}
+
+type Result struct {
+	Run
+	Summary server.RunSummary
+}
+
+// ParseParameter reads "name=v1,v2,..." or "name=start:stop:step"
+func ParseParameter(spec string) (Parameter, error) {
+	name, valueSpec, found := strings.Cut(spec, "=")
+	if !found || name == "" || valueSpec == "" {
+		return Parameter{}, fmt.Errorf("invalid sweep parameter %q, expected name=values", spec)
+	}
+
+	if bounds := strings.Split(valueSpec, ":"); len(bounds) == 3 {
+		values, err := expandRange(bounds[0], bounds[1], bounds[2])
+		if err != nil {
+			return Parameter{}, fmt.Errorf("invalid range for %s: %w", name, err)
+		}
+		return Parameter{Name: name, Values: values}, nil
+	}
+
+	return Parameter{Name: name, Values: strings.Split(valueSpec, ",")}, nil
+}
+
+func expandRange(startStr, stopStr, stepStr string) ([]string, error) {
+	start, err := strconv.ParseFloat(startStr, 64)
+	if err != nil {
+		return nil, err
+	}
+	stop, err := strconv.ParseFloat(stopStr, 64)
+	if err != nil {
+		return nil, err
+	}
+	step, err := strconv.ParseFloat(stepStr, 64)
+	if err != nil {
+		return nil, err
+	}
+	if step <= 0 || stop < start {
+		return nil, fmt.Errorf("range %s:%s:%s is empty", startStr, stopStr, stepStr)
+	}
+
+	values := make([]string, 0)
+	// multiply rather than accumulate so 0:1:0.1 doesn't drift past 1
+	numSteps := int(math.Floor((stop-start)/step + 1e-9))
+	for i := 0; i <= numSteps; i++ {
+		value := math.Round((start+float64(i)*step)*1e9) / 1e9
+		values = append(values, strconv.FormatFloat(value, 'f', -1, 64))
+	}
+	return values, nil
+}
+
+// Runs expands the sweep into one Run per parameter combination and replicate.
+// Replicate r is seeded with Base.Seed+r in every cell, so cells are compared
+// under common random numbers.
+func (s Sweep) Runs() ([]Run, error) {
+	combinations := [][]string{{}}
+	for _, param := range s.Parameters {
+		next := make([][]string, 0, len(combinations)*len(param.Values))
+		for _, combination := range combinations {
+			for _, value := range param.Values {
+				next = append(next, append(append([]string{}, combination...), value))
+			}
+		}
+		combinations = next
+	}
+
+	runs := make([]Run, 0, len(combinations)*s.Replicates)
+	for _, combination := range combinations {
+		for replicate := range s.Replicates {
+			cfg := s.Base
+			for i, param := range s.Parameters {
+				if err := cfg.Set(param.Name, combination[i]); err != nil {
+					return nil, err
+				}
+			}
+			cfg.Seed = s.Base.Seed + int64(replicate)
+			cfg.Debug = false
+			if err := cfg.Validate(); err != nil {
+				return nil, fmt.Errorf("invalid sweep cell %v: %w", combination, err)
+			}
+			runs = append(runs, Run{
+				Index:     len(runs),
+				Replicate: replicate,
+				Values:    combination,
+				Config:    cfg,
+			})
+		}
+	}
+	return runs, nil
+}
+
+// Execute plays every run across a pool of workers. Results are returned in run order.
+func (s Sweep) Execute(progress func(completed, total int)) ([]Result, error) {
+	runs, err := s.Runs()
+	if err != nil {
+		return nil, err
+	}
+
+	workers := s.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	results := make([]Result, len(runs))
+	jobs := make(chan Run)
+	var wg sync.WaitGroup
+	var progressMutex sync.Mutex
+	completed := 0
+
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for run := range jobs {
+				results[run.Index] = Result{Run: run, Summary: simulate(run.Config)}
+				if progress != nil {
+					progressMutex.Lock()
+					completed++
+					progress(completed, len(runs))
+					progressMutex.Unlock()
+				}
+			}
+		}()
+	}
+
+	for _, run := range runs {
+		jobs <- run
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results, nil
+}
+
+func simulate(cfg config.Config) server.RunSummary {
+	serv := server.CreateTMTServer(cfg)
+	serv.SetGameRunner(serv)
+	serv.CreateInitialPopulation()
+	serv.Run()
+	return serv.GetRunSummary()
+}
@@ -0,0 +1,62 @@
+package tests
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/aaashah/TMT_FYP/config"
+	"github.com/aaashah/TMT_FYP/infra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestASMWeightsFromFlagsAndFile(t *testing.T) {
+	path := writeScenario(t, `{"ASMWeights": {"MortalitySalience": {"ClusterEliminations": 0.4, "NetworkEliminations": 0.4, "RelativeAge": 0.2, "MemorialProximity": 0}}}`)
+
+	cfg, err := config.Load("test", []string{"-config", path}, flag.ContinueOnError)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.4, cfg.ASMWeights.MortalitySalience.ClusterEliminations)
+	assert.Equal(t, 0.0, cfg.ASMWeights.MortalitySalience.MemorialProximity)
+	// modules missing from the file keep their defaults
+	assert.Equal(t, config.DefaultConfig().ASMWeights.WorldviewValidation, cfg.ASMWeights.WorldviewValidation)
+
+	cfg, err = config.Load("test", []string{"-w_est", "0.5", "-w_pse", "0.5", "-w_heroism", "0"}, flag.ContinueOnError)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.5, cfg.ASMWeights.RelationshipValidation.Estrangement)
+}
+
+func TestASMWeightsValidated(t *testing.T) {
+	_, err := config.Load("test", []string{"-w_ce", "0.5"}, flag.ContinueOnError)
+	assert.Error(t, err, "Mortality salience weights no longer sum to 1")
+
+	_, err = config.Load("test", []string{"-w_cpr", "-0.2", "-w_npr", "0.86", "-w_ysterofimia", "0.34"}, flag.ContinueOnError)
+	assert.Error(t, err, "Negative weights should be rejected")
+}
+
+func TestAttachmentASMWeightOverrides(t *testing.T) {
+	path := writeScenario(t, `{"AttachmentASMWeights": {"Secure": {"MortalitySalience": {"ClusterEliminations": 0.5, "NetworkEliminations": 0.5, "RelativeAge": 0, "MemorialProximity": 0}}}}`)
+
+	cfg, err := config.Load("test", []string{"-config", path}, flag.ContinueOnError)
+	assert.NoError(t, err)
+
+	secure := cfg.WeightsFor("Secure")
+	assert.Equal(t, 0.0, secure.MortalitySalience.MemorialProximity)
+	assert.Equal(t, cfg.ASMWeights.WorldviewValidation, secure.WorldviewValidation)
+	assert.Equal(t, cfg.ASMWeights, cfg.WeightsFor("Fearful"))
+
+	path = writeScenario(t, `{"AttachmentASMWeights": {"Secure": {"MortalitySalience": {"ClusterEliminations": 0.5}}}}`)
+	_, err = config.Load("test", []string{"-config", path}, flag.ContinueOnError)
+	assert.Error(t, err, "A partially specified module should fail to sum to 1")
+
+	path = writeScenario(t, `{"AttachmentASMWeights": {"Avoidant": {}}}`)
+	_, err = config.Load("test", []string{"-config", path}, flag.ContinueOnError)
+	assert.Error(t, err, "Unknown attachment styles should be rejected")
+}
+
+func TestOverridesAcceptEveryAttachmentType(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.AttachmentASMWeights = make(map[string]config.ASMWeightOverride)
+	for _, attachType := range infra.AllAttachmentTypes {
+		cfg.AttachmentASMWeights[attachType.String()] = config.ASMWeightOverride{}
+	}
+	assert.NoError(t, cfg.Validate())
+}
@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/aaashah/TMT_FYP/config"
+	"github.com/aaashah/TMT_FYP/infra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetASMDecision(t *testing.T) {
+	tests := []struct {
+		name       string
+		ms, wv, rv float32
+		threshold  float32
+		expected   infra.ASMDecison
+	}{
+		{
+			name: "All above threshold -> self-sacrifice",
+			ms:   0.8, wv: 0.9, rv: 0.85,
+			threshold: 0.5,
+			expected:  infra.SELF_SACRIFICE,
+		},
+		{
+			name: "All below threshold -> not self-sacrifice",
+			ms:   0.3, wv: 0.2, rv: 0.1,
+			threshold: 0.5,
+			expected:  infra.NOT_SELF_SACRIFICE,
+		},
+		{
+			name: "Zero threshold -> should treat thresholdScore correctly and rely on sign of sum",
+			ms:   0.4, wv: 0.6, rv: 0.8,
+			threshold: 0.0,
+			expected:  infra.SELF_SACRIFICE,
+		},
+		{
+			name: "Two of three above threshold -> self-sacrifice",
+			ms:   0.6, wv: 0.7, rv: 0.1,
+			threshold: 0.5,
+			expected:  infra.SELF_SACRIFICE,
+		},
+	}
+
+	rule := infra.MajorityVoteRule{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scores := infra.ModuleScores{MortalitySalience: tt.ms, WorldviewValidation: tt.wv, RelationshipValidation: tt.rv}
+			decision := rule.Decide(scores, tt.threshold, nil)
+			if decision != tt.expected {
+				t.Errorf("Expected %v, got %v (ms=%.2f, wv=%.2f, rv=%.2f, threshold=%.2f)", tt.expected, decision, tt.ms, tt.wv, tt.rv, tt.threshold)
+			}
+		})
+	}
+}
+
+func newDecisionRule(t *testing.T, name string) infra.DecisionRule {
+	cfg := config.DefaultConfig()
+	cfg.DecisionRule = name
+	rule, err := infra.NewDecisionRule(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rule
+}
+
+func uniformScores(score float32) infra.ModuleScores {
+	return infra.ModuleScores{MortalitySalience: score, WorldviewValidation: score, RelationshipValidation: score}
+}
+
+func TestWeightedSumAndMarginRules(t *testing.T) {
+	weighted := newDecisionRule(t, "weighted")
+	// one strong module outweighs two weak ones under the weighted sum, but not under the vote
+	scores := infra.ModuleScores{MortalitySalience: 1.0, WorldviewValidation: 0.4, RelationshipValidation: 0.4}
+	assert.Equal(t, infra.SELF_SACRIFICE, weighted.Decide(scores, 0.5, nil))
+	assert.Equal(t, infra.NOT_SELF_SACRIFICE, infra.MajorityVoteRule{}.Decide(scores, 0.5, nil))
+
+	margin := newDecisionRule(t, "margin") // default margin of 0.05
+	assert.Equal(t, infra.SELF_SACRIFICE, margin.Decide(uniformScores(0.6), 0.5, nil))
+	assert.Equal(t, infra.INACTION, margin.Decide(uniformScores(0.52), 0.5, nil))
+	assert.Equal(t, infra.INACTION, margin.Decide(uniformScores(0.48), 0.5, nil))
+	assert.Equal(t, infra.NOT_SELF_SACRIFICE, margin.Decide(uniformScores(0.4), 0.5, nil))
+}
+
+func TestProbabilisticRules(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 1))
+	countDecisions := func(rule infra.DecisionRule, score float32) map[infra.ASMDecison]int {
+		counts := make(map[infra.ASMDecison]int)
+		for range 2000 {
+			counts[rule.Decide(uniformScores(score), 0.5, rng)]++
+		}
+		return counts
+	}
+
+	logistic := newDecisionRule(t, "logistic")
+	assert.InDelta(t, 1000, countDecisions(logistic, 0.5)[infra.SELF_SACRIFICE], 100, "At tau volunteering is a coin flip")
+	assert.Greater(t, countDecisions(logistic, 1.0)[infra.SELF_SACRIFICE], 1950, "Far above tau at low temperature nearly everyone volunteers")
+	assert.Zero(t, countDecisions(logistic, 0.5)[infra.INACTION])
+
+	softmax := newDecisionRule(t, "softmax")
+	atTau := countDecisions(softmax, 0.5)
+	for _, decision := range []infra.ASMDecison{infra.SELF_SACRIFICE, infra.NOT_SELF_SACRIFICE, infra.INACTION} {
+		assert.InDelta(t, 2000.0/3, atTau[decision], 100, "At tau every choice is equally likely")
+	}
+	assert.Greater(t, countDecisions(softmax, 1.0)[infra.SELF_SACRIFICE], 1950)
+}
+
+func TestDecisionRuleValidation(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.DecisionRule = "unanimous"
+	assert.Error(t, cfg.Validate())
+
+	cfg.DecisionRule = "softmax"
+	cfg.DecisionTemperature = 0
+	assert.Error(t, cfg.Validate())
+
+	cfg = config.DefaultConfig()
+	cfg.ModuleWeights.MortalitySalience = 0.5
+	assert.Error(t, cfg.Validate())
+}
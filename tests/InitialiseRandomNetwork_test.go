@@ -13,7 +13,7 @@ import (
 
 func TestInitialiseRandomNetwork(t *testing.T) {
 	// Initialize server and config
-	conf := config.NewConfig()
+	conf := config.DefaultConfig()
 	serv := server.CreateTMTServer(conf)
 
 	// Create the main agent and set position
@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aaashah/TMT_FYP/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeScenario(t *testing.T, contents string) string {
+	path := filepath.Join(t.TempDir(), "scenario.json")
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigFromScenarioFile(t *testing.T) {
+	path := writeScenario(t, `{"GridWidth": 20, "PopulationRho": 0.4, "Seed": 7}`)
+
+	cfg, err := config.Load("test", []string{"-config", path}, flag.ContinueOnError)
+	assert.NoError(t, err)
+	assert.Equal(t, 20, cfg.GridWidth)
+	assert.Equal(t, 0.4, cfg.PopulationRho)
+	assert.Equal(t, int64(7), cfg.Seed)
+	// fields missing from the file keep their defaults
+	assert.Equal(t, config.DefaultConfig().GridHeight, cfg.GridHeight)
+}
+
+func TestLoadConfigFlagsOverrideFile(t *testing.T) {
+	path := writeScenario(t, `{"GridWidth": 20, "PopulationRho": 0.4}`)
+
+	// flag order relative to -config should not matter
+	cfg, err := config.Load("test", []string{"-rho", "0.1", "-config", path}, flag.ContinueOnError)
+	assert.NoError(t, err)
+	assert.Equal(t, 20, cfg.GridWidth)
+	assert.Equal(t, 0.1, cfg.PopulationRho)
+}
+
+func TestLoadConfigRejectsUnknownKeys(t *testing.T) {
+	path := writeScenario(t, `{"GridWidth": 20, "ProccupiedProp": 0.25}`)
+
+	_, err := config.Load("test", []string{"-config", path}, flag.ContinueOnError)
+	assert.Error(t, err)
+}
+
+func TestConfigRoundTrip(t *testing.T) {
+	original := config.DefaultConfig()
+	original.Seed = 99
+	original.Debug = true
+	original.PreoccupiedProp = 0.4
+	original.SecureProp = 0.1
+
+	data, err := json.Marshal(original)
+	assert.NoError(t, err)
+
+	cfg, err := config.Load("test", []string{"-config", writeScenario(t, string(data))}, flag.ContinueOnError)
+	assert.NoError(t, err)
+	assert.Equal(t, original, cfg)
+}
+
+func TestLoadConfigForksCheckpoint(t *testing.T) {
+	path := writeScenario(t, `{"Config": {"GridWidth": 20, "PopulationRho": 0.4}, "Iteration": 3}`)
+
+	cfg, err := config.Load("test", []string{"-resume", path, "-rho", "0.1"}, flag.ContinueOnError)
+	assert.NoError(t, err)
+	assert.Equal(t, path, cfg.Resume)
+	assert.Equal(t, 20, cfg.GridWidth, "Parameters not overridden come from the checkpoint")
+	assert.Equal(t, 0.1, cfg.PopulationRho, "Flags override the checkpoint's parameters")
+}
+
+func TestScenarioTimeline(t *testing.T) {
+	path := writeScenario(t, `{"Events": [
+		{"Iteration": 10, "Type": "rho", "Value": 0.5, "Duration": 5},
+		{"Iteration": 12, "Type": "rho", "Value": 0.1},
+		{"Iteration": 20, "Type": "catastrophe", "Cluster": 0, "Fraction": 0.3}
+	]}`)
+
+	cfg, err := config.Load("test", []string{"-config", path}, flag.ContinueOnError)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.2, cfg.ParameterAt(config.EventRho, cfg.PopulationRho, 9))
+	assert.Equal(t, 0.5, cfg.ParameterAt(config.EventRho, cfg.PopulationRho, 11))
+	assert.Equal(t, 0.1, cfg.ParameterAt(config.EventRho, cfg.PopulationRho, 13), "Later events override earlier ones")
+	assert.Equal(t, 0.1, cfg.ParameterAt(config.EventRho, cfg.PopulationRho, 16))
+	if assert.NotNil(t, cfg.Events[2].Cluster) {
+		assert.Equal(t, 0, *cfg.Events[2].Cluster)
+	}
+
+	for _, bad := range []string{
+		`{"Events": [{"Iteration": 1, "Type": "flood"}]}`,
+		`{"Events": [{"Iteration": 1, "Type": "rho", "Value": 1.5}]}`,
+		`{"Events": [{"Iteration": 1, "Type": "catastrophe", "Cluster": 3, "Fraction": 0.5}]}`,
+		`{"Events": [{"Iteration": 1, "Type": "immigration", "Count": 2, "Attachment": "Anxious"}]}`,
+	} {
+		_, err := config.Load("test", []string{"-config", writeScenario(t, bad)}, flag.ContinueOnError)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestScenarioSchedules(t *testing.T) {
+	path := writeScenario(t, `{"RhoSchedule": {"Type": "linear", "EndIteration": 50, "From": 0.1, "To": 0.4}}`)
+	cfg, err := config.Load("test", []string{"-config", path}, flag.ContinueOnError)
+	assert.NoError(t, err)
+	if assert.NotNil(t, cfg.RhoSchedule) {
+		assert.Equal(t, 0.4, cfg.RhoSchedule.To)
+	}
+	assert.Nil(t, cfg.TauSchedule)
+
+	for _, bad := range []string{
+		`{"RhoSchedule": {"Type": "exponential"}}`,
+		`{"TauSchedule": {"Type": "sine", "Mean": 0.5, "Amplitude": 0.2}}`,
+		`{"RhoSchedule": {"Type": "sine", "Mean": 0.9, "Amplitude": 0.2, "Period": 10}}`,
+		`{"RhoSchedule": {"Type": "step", "Steps": [{"Iteration": 5, "Value": 0.1}, {"Iteration": 2, "Value": 0.3}]}}`,
+	} {
+		_, err := config.Load("test", []string{"-config", writeScenario(t, bad)}, flag.ContinueOnError)
+		assert.Error(t, err, bad)
+	}
+}
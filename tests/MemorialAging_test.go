@@ -0,0 +1,47 @@
+package tests
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/aaashah/TMT_FYP/infra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemorialAging(t *testing.T) {
+	grid := infra.NewGrid(10, 10, rand.New(rand.NewPCG(1, 1)))
+	grid.Aging = infra.MemorialAging{Decay: 0.5, Lifetime: 2}
+	grid.PlaceTombstone(1, 1, 0)
+	grid.PlaceTemple(2, 2, 0)
+	grid.PlaceTombstone(3, 3, 1)
+
+	assert.Equal(t, 0, grid.AgeMemorials(1))
+	assert.Equal(t, 0.5, grid.Tombstones()[0].Salience)
+	assert.Equal(t, 1.0, grid.Tombstones()[1].Salience, "New memorials are at full salience")
+	assert.Equal(t, 0.5, grid.Temples()[0].Salience)
+
+	assert.Equal(t, 0, grid.AgeMemorials(2))
+	assert.Equal(t, 0.25, grid.Tombstones()[0].Salience)
+
+	// memorials older than their lifetime are removed and their cells freed
+	assert.Equal(t, 2, grid.AgeMemorials(3))
+	assert.Len(t, grid.Tombstones(), 1)
+	assert.Empty(t, grid.Temples())
+	assert.Equal(t, 2, grid.Tombstones()[0].Age(3))
+	assert.False(t, grid.IsOccupied(1, 1))
+	assert.Len(t, grid.FindPath(infra.PositionVector{X: 1, Y: 0}, infra.PositionVector{X: 1, Y: 2}, infra.PathOptions{SearchLimit: 500}), 2,
+		"Routes go through a removed memorial's cell")
+}
+
+func TestPermanentTemples(t *testing.T) {
+	grid := infra.NewGrid(10, 10, rand.New(rand.NewPCG(1, 1)))
+	grid.Aging = infra.MemorialAging{Decay: 0.5, Lifetime: 2, PermanentTemples: true}
+	grid.PlaceTombstone(1, 1, 0)
+	grid.PlaceTemple(2, 2, 0)
+
+	grid.AgeMemorials(10)
+	assert.Empty(t, grid.Tombstones())
+	assert.Len(t, grid.Temples(), 1)
+	assert.Equal(t, 1.0, grid.Temples()[0].Salience)
+	assert.Len(t, grid.Memorials(), 1)
+}
@@ -16,7 +16,7 @@ import (
 
 func TestRelativeAgeToNetwork(t *testing.T) {
 	// Initialize server and config
-	conf := config.NewConfig()
+	conf := config.DefaultConfig()
 	serv := server.CreateTMTServer(conf)
 
 	// Create the main agent
@@ -29,52 +29,45 @@ func TestRelativeAgeToNetwork(t *testing.T) {
 	friend3 := agents.CreateSecureAgent(serv)
 
 	//new telomeres for friends
-	friend1Age := 20 // 20 years old
-	for agent.GetAge() < friend1Age {
-		agent.IncrementAge()
+	for friend, age := range map[infra.IExtendedAgent]int{friend1: 20, friend2: 30, friend3: 40} {
+		for friend.GetAge() < age {
+			friend.IncrementAge()
+		}
+		serv.AddAgent(friend)
 	}
 
-	friend2Age := 30 // 30 years old
-	for agent.GetAge() < friend2Age {
-		agent.IncrementAge()
-	}
-
-	friend3Age := 40 // 40 years old
-	for agent.GetAge() < friend3Age {
+	// the agent is older than one friend and younger than two
+	for agent.GetAge() < 25 {
 		agent.IncrementAge()
 	}
 
 	// Add friends to the agent's social network (weights arbitrary)
-	serv.CreateNetworkConnection(agent.GetID(), friend1.GetID(), 1.0)
-	serv.CreateNetworkConnection(agent.GetID(), friend2.GetID(), 1.0)
-	serv.CreateNetworkConnection(agent.GetID(), friend3.GetID(), 1.0)
-
 	agent.AddToSocialNetwork(friend1.GetID(), 1.0)
 	agent.AddToSocialNetwork(friend2.GetID(), 1.0)
 	agent.AddToSocialNetwork(friend3.GetID(), 1.0)
 
-	// Test: should return the average age of friends in the network
-	expectedAvgAge := (20 + 30 + 40) / 3.0
-	assert.Equal(t, expectedAvgAge, agent.RelativeAgeToNetwork())
+	// Test: should return the agent's rank by age within its network, as a fraction
+	expectedRank := float32(2) / 3
+	assert.Equal(t, expectedRank, agent.RelativeAgeToNetwork())
 }
 
 // Testing memorial proximity
 func TestMemorialProximity(t *testing.T) {
 	// Setup
-	conf := config.NewConfig()
+	conf := config.DefaultConfig()
 	serv := server.CreateTMTServer(conf)
 
+	grid := serv.GetGrid()
+
 	// Create the main agent
 	agent := agents.CreateSecureAgent(serv)
 	serv.AddAgent(agent)
 	agent.SetPosition(infra.PositionVector{X: 0, Y: 0})
 	agent.SetClusterID(1)
+	grid.PlaceAgent(agent, agent.GetPosition())
 
-	// Create grid with one tombstone at (1, 0)
-	grid := &infra.Grid{
-		Tombstones: []infra.PositionVector{{X: 1, Y: 0}},
-		Temples:    []infra.PositionVector{},
-	}
+	// Place one tombstone at (1, 0)
+	grid.PlaceTombstone(1, 0, 0)
 
 	// No other agents in the cluster
 	proximity := agent.GetMemorialProximity(grid)
@@ -85,6 +78,7 @@ func TestMemorialProximity(t *testing.T) {
 	serv.AddAgent(friend)
 	friend.SetPosition(infra.PositionVector{X: 0, Y: 1})
 	friend.SetClusterID(1)
+	grid.PlaceAgent(friend, friend.GetPosition())
 
 	proximity = agent.GetMemorialProximity(grid)
 	// compute expected influences
@@ -97,8 +91,14 @@ func TestMemorialProximity(t *testing.T) {
 	expectedProximity := float32(clusterInfluence) / float32(clusterInfluence+memorialInfluence)
 	assert.InDelta(t, expectedProximity, proximity, 1e-6, "Equal influence from friend and memorial")
 
+	// A faded memorial counts for less
+	grid.Aging = infra.MemorialAging{Decay: 0.5, Lifetime: 1}
+	grid.AgeMemorials(1)
+	proximity = agent.GetMemorialProximity(grid)
+	assert.InDelta(t, float32(1/1.5), proximity, 1e-6, "Half-faded memorial has half the influence")
+
 	// Remove memorial, test only cluster influence
-	grid.Tombstones = []infra.PositionVector{}
+	grid.AgeMemorials(2)
 	proximity = agent.GetMemorialProximity(grid)
 	assert.Equal(t, float32(1.0), proximity, "Only cluster influence → proximity 1")
 
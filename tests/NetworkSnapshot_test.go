@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aaashah/TMT_FYP/gameRecorder"
+	"github.com/aaashah/TMT_FYP/server"
+	"github.com/stretchr/testify/assert"
+)
+
+type graphMLFile struct {
+	Graph struct {
+		EdgeDefault string `xml:"edgedefault,attr"`
+		Nodes       []struct {
+			ID string `xml:"id,attr"`
+		} `xml:"node"`
+		Edges []struct {
+			Source string `xml:"source,attr"`
+			Target string `xml:"target,attr"`
+			Data   []struct {
+				Key   string `xml:"key,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"data"`
+		} `xml:"edge"`
+	} `xml:"graph"`
+}
+
+func TestWriteGraphML(t *testing.T) {
+	snapshot := gameRecorder.NetworkSnapshot{
+		Iteration: 3,
+		Nodes: []gameRecorder.NetworkNode{
+			{ID: "a", AttachmentStyle: "Secure", ClusterID: 0, Age: 2, Heroism: 1},
+			{ID: "b", AttachmentStyle: "Fearful", ClusterID: 1, Age: 5, Heroism: 0},
+		},
+		Edges: []gameRecorder.NetworkEdge{{Source: "a", Target: "b", Weight: 0.75}},
+	}
+
+	var output bytes.Buffer
+	assert.NoError(t, gameRecorder.WriteGraphML(&output, snapshot))
+
+	var parsed graphMLFile
+	assert.NoError(t, xml.Unmarshal(output.Bytes(), &parsed))
+	assert.Equal(t, "directed", parsed.Graph.EdgeDefault)
+	assert.Len(t, parsed.Graph.Nodes, 2)
+	if assert.Len(t, parsed.Graph.Edges, 1) {
+		edge := parsed.Graph.Edges[0]
+		assert.Equal(t, "a", edge.Source)
+		assert.Equal(t, "b", edge.Target)
+		assert.Equal(t, "weight", edge.Data[0].Key)
+		assert.Equal(t, "0.75", edge.Data[0].Value)
+	}
+}
+
+func TestNetworkSnapshotsWrittenEveryNthIteration(t *testing.T) {
+	outputDir := t.TempDir()
+	conf := smallRunConfig(outputDir)
+	conf.NumIterations = 5
+	conf.NetworkSnapshotEvery = 2
+	serv := server.CreateTMTServer(conf)
+	serv.SetGameRunner(serv)
+	serv.CreateInitialPopulation()
+	serv.Start()
+
+	snapshots, err := filepath.Glob(filepath.Join(onlyRunDir(t, outputDir), "networks", "*.graphml"))
+	assert.NoError(t, err)
+	names := make([]string, 0, len(snapshots))
+	for _, path := range snapshots {
+		names = append(names, filepath.Base(path))
+	}
+	assert.Equal(t, []string{"iteration_0000.graphml", "iteration_0002.graphml", "iteration_0004.graphml"}, names)
+
+	data, err := os.ReadFile(snapshots[len(snapshots)-1])
+	assert.NoError(t, err)
+	var parsed graphMLFile
+	assert.NoError(t, xml.Unmarshal(data, &parsed))
+	assert.Len(t, parsed.Graph.Nodes, len(serv.GetAgentMap()))
+}
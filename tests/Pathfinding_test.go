@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/aaashah/TMT_FYP/infra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindPathAroundMemorials(t *testing.T) {
+	grid := infra.NewGrid(10, 10, rand.New(rand.NewPCG(1, 1)))
+	opts := infra.PathOptions{SearchLimit: 500}
+	// a wall of tombstones across x = 5 with a gap at y = 9
+	for y := range 9 {
+		grid.PlaceTombstone(5, y, 0)
+	}
+	from, target := infra.PositionVector{X: 2, Y: 0}, infra.PositionVector{X: 8, Y: 0}
+
+	path := grid.FindPath(from, target, opts)
+	assert.Equal(t, target, path[len(path)-1])
+	assert.Contains(t, path, infra.PositionVector{X: 5, Y: 9}, "The route goes through the gap")
+	for i, pos := range path {
+		previous := from
+		if i > 0 {
+			previous = path[i-1]
+		}
+		assert.Equal(t, 1.0, grid.Dist(previous, pos), "Each step is to a neighbouring cell")
+		_, ok := grid.CanEnter(pos)
+		assert.True(t, ok, "The route never crosses a memorial")
+	}
+
+	// closing the gap walls the target off, so the route ends at the wall
+	grid.PlaceTemple(5, 9, 0)
+	path = grid.FindPath(from, target, opts)
+	assert.Equal(t, infra.PositionVector{X: 4, Y: 0}, path[len(path)-1])
+
+	// a memorial target is swapped for the nearest cell without one
+	path = grid.FindPath(from, infra.PositionVector{X: 5, Y: 0}, opts)
+	assert.Equal(t, infra.PositionVector{X: 4, Y: 0}, path[len(path)-1])
+
+	assert.Empty(t, grid.FindPath(target, target, opts))
+}
+
+func TestMemorialPathCost(t *testing.T) {
+	grid := infra.NewGrid(10, 10, rand.New(rand.NewPCG(1, 1)))
+	grid.PlaceTombstone(5, 4, 0)
+	from, target := infra.PositionVector{X: 2, Y: 5}, infra.PositionVector{X: 8, Y: 5}
+
+	direct := grid.FindPath(from, target, infra.PathOptions{SearchLimit: 500})
+	assert.Len(t, direct, 6, "Without a cost the route passes next to the tombstone")
+	assert.Contains(t, direct, infra.PositionVector{X: 5, Y: 5})
+
+	wary := grid.FindPath(from, target, infra.PathOptions{MemorialCost: 5, SearchLimit: 500})
+	assert.NotContains(t, wary, infra.PositionVector{X: 5, Y: 5}, "A high cost keeps the route away from it")
+	assert.Greater(t, len(wary), len(direct))
+}
+
+func TestFindPathOffTheGrid(t *testing.T) {
+	opts := infra.PathOptions{SearchLimit: 500}
+	from := infra.PositionVector{X: 1, Y: 1}
+	bounded := infra.NewGrid(10, 10, rand.New(rand.NewPCG(1, 1)))
+	path := bounded.FindPath(from, infra.PositionVector{X: -5, Y: 1}, opts)
+	assert.Equal(t, []infra.PositionVector{{X: 0, Y: 1}}, path, "Targets off a bounded grid are clamped to its edge")
+
+	toroidal := newTopologyGrid(t, "toroidal")
+	path = toroidal.FindPath(from, infra.PositionVector{X: -2, Y: 1}, opts)
+	assert.Equal(t, []infra.PositionVector{{X: 0, Y: 1}, {X: 9, Y: 1}, {X: 8, Y: 1}}, path, "Targets off a torus wrap round")
+}
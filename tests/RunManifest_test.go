@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aaashah/TMT_FYP/config"
+	"github.com/aaashah/TMT_FYP/gameRecorder"
+	"github.com/aaashah/TMT_FYP/server"
+	"github.com/stretchr/testify/assert"
+)
+
+func smallRunConfig(outputDir string) config.Config {
+	conf := config.DefaultConfig()
+	conf.NumAgents = 12
+	conf.NumIterations = 3
+	conf.NumTurns = 2
+	conf.GridWidth = 15
+	conf.GridHeight = 15
+	conf.OutputDir = outputDir
+	return conf
+}
+
+func readManifest(t *testing.T, runDir string) gameRecorder.RunManifest {
+	data, err := os.ReadFile(filepath.Join(runDir, "manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest gameRecorder.RunManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	return manifest
+}
+
+func onlyRunDir(t *testing.T, outputDir string) string {
+	matches, err := filepath.Glob(filepath.Join(outputDir, "*_seed*"))
+	if err != nil || len(matches) != 1 {
+		t.Fatalf("expected exactly one run directory, found %v", matches)
+	}
+	return matches[0]
+}
+
+func TestRunWritesOwnDirectoryAndManifest(t *testing.T) {
+	outputDir := t.TempDir()
+	conf := smallRunConfig(outputDir)
+	serv := server.CreateTMTServer(conf)
+	serv.SetGameRunner(serv)
+	serv.CreateInitialPopulation()
+	serv.Start()
+
+	runDir := onlyRunDir(t, outputDir)
+	assert.FileExists(t, filepath.Join(runDir, "output.ndjson"))
+	assert.FileExists(t, filepath.Join(runDir, "lineage_agents.csv"))
+	assert.FileExists(t, filepath.Join(runDir, "lineage_edges.csv"))
+
+	latest, err := os.ReadFile(filepath.Join(outputDir, "LATEST"))
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Base(runDir), strings.TrimSpace(string(latest)))
+
+	manifest := readManifest(t, runDir)
+	assert.True(t, manifest.Completed)
+	assert.Equal(t, conf.Seed, manifest.Seed)
+	// output options such as OutputDir are not serialised, so compare the model parameters
+	assert.Equal(t, gameRecorder.ConfigHash(conf), gameRecorder.ConfigHash(manifest.Config))
+	assert.Equal(t, len(serv.GetAgentMap()), manifest.FinalPopulation)
+	assert.True(t, strings.HasSuffix(manifest.RunID, gameRecorder.ConfigHash(conf)))
+}
+
+func TestManifestWrittenWhenRunPanics(t *testing.T) {
+	outputDir := t.TempDir()
+	conf := smallRunConfig(outputDir)
+	conf.NumClusters = 0 // k-means cannot assign agents to zero clusters
+	serv := server.CreateTMTServer(conf)
+	serv.SetGameRunner(serv)
+	serv.CreateInitialPopulation()
+
+	assert.Panics(t, serv.Start)
+
+	manifest := readManifest(t, onlyRunDir(t, outputDir))
+	assert.False(t, manifest.Completed)
+	assert.NotEmpty(t, manifest.Error)
+}
@@ -0,0 +1,210 @@
+package tests
+
+import (
+	"bytes"
+	"cmp"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/aaashah/TMT_FYP/agents"
+	"github.com/aaashah/TMT_FYP/config"
+	"github.com/aaashah/TMT_FYP/infra"
+	"github.com/aaashah/TMT_FYP/server"
+	"github.com/stretchr/testify/assert"
+)
+
+// newIndexedGrid scatters agents over free cells of a width x height grid, with
+// every seventh cell holding a tombstone
+func newIndexedGrid(topology string, width, height, numAgents int) (*infra.Grid, []infra.IExtendedAgent) {
+	conf := config.DefaultConfig()
+	conf.GridWidth, conf.GridHeight, conf.Topology = width, height, topology
+	serv := server.CreateTMTServer(conf)
+	grid := serv.GetGrid()
+	for cell := 0; cell < width*height; cell += 7 {
+		grid.PlaceTombstone(cell/height, cell%height, 0)
+	}
+	placed := make([]infra.IExtendedAgent, 0, numAgents)
+	for range numAgents {
+		agent := agents.CreateFearfulAgent(serv)
+		pos, _ := grid.RandomFreeCell()
+		agent.SetPosition(pos)
+		agent.SetClusterID(len(placed) % 3)
+		grid.PlaceAgent(agent, pos)
+		placed = append(placed, agent)
+	}
+	return grid, placed
+}
+
+// scanNearest is the linear scan the spatial index replaces
+func scanNearest(grid *infra.Grid, pos infra.PositionVector, k int, placed []infra.IExtendedAgent, keep func(infra.IExtendedAgent) bool) []infra.IExtendedAgent {
+	kept := make([]infra.IExtendedAgent, 0)
+	for _, agent := range placed {
+		if keep(agent) {
+			kept = append(kept, agent)
+		}
+	}
+	slices.SortStableFunc(kept, func(a, b infra.IExtendedAgent) int {
+		if c := cmp.Compare(grid.Dist(pos, a.GetPosition()), grid.Dist(pos, b.GetPosition())); c != 0 {
+			return c
+		}
+		aID, bID := a.GetID(), b.GetID()
+		return bytes.Compare(aID[:], bID[:])
+	})
+	return kept[:min(k, len(kept))]
+}
+
+func TestSpatialIndexMatchesScan(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 3))
+	for _, topology := range []string{"bounded", "toroidal"} {
+		grid, placed := newIndexedGrid(topology, 37, 21, 60)
+		for range 50 {
+			pos := infra.PositionVector{X: rng.IntN(37), Y: rng.IntN(21)}
+			cluster := rng.IntN(3)
+			keep := func(agent infra.IExtendedAgent) bool { return agent.GetClusterID() == cluster }
+			assert.Equal(t, scanNearest(grid, pos, 3, placed, keep), grid.NearestAgents(pos, 3, keep), topology)
+
+			radius := rng.Float64() * 15
+			within := scanNearest(grid, pos, len(placed), placed, func(agent infra.IExtendedAgent) bool {
+				return keep(agent) && grid.Dist(pos, agent.GetPosition()) <= radius
+			})
+			slices.SortFunc(within, func(a, b infra.IExtendedAgent) int {
+				aID, bID := a.GetID(), b.GetID()
+				return bytes.Compare(aID[:], bID[:])
+			})
+			assert.Equal(t, within, grid.AgentsWithin(pos, radius, keep), topology)
+
+			memorials := slices.DeleteFunc(grid.Memorials(), func(memorial infra.Memorial) bool {
+				return grid.Dist(pos, memorial.PositionVector) > radius
+			})
+			assert.Equal(t, memorials, grid.MemorialsWithin(pos, radius), topology)
+		}
+	}
+}
+
+func TestSpatialIndexFollowsAgents(t *testing.T) {
+	grid, placed := newIndexedGrid("bounded", 20, 20, 2)
+	everyone := func(infra.IExtendedAgent) bool { return true }
+	mover, other := placed[0], placed[1]
+	origin := mover.GetPosition()
+
+	// move the other agent next to the origin, well away from its old bucket
+	target, _ := grid.FreeCellNear(infra.Centroid{X: float64(origin.X), Y: float64(origin.Y)}, 1)
+	assert.True(t, grid.UpdateAgentPosition(other, target))
+	other.SetPosition(target)
+	nearest, ok := grid.NearestAgent(origin, func(agent infra.IExtendedAgent) bool { return agent != mover })
+	assert.True(t, ok)
+	assert.Same(t, other, nearest)
+
+	grid.RemoveAgent(other)
+	assert.Equal(t, []infra.IExtendedAgent{mover}, grid.AgentsWithin(origin, 100, everyone))
+	_, ok = grid.NearestAgent(origin, func(agent infra.IExtendedAgent) bool { return agent != mover })
+	assert.False(t, ok, "Removed agents are no longer found")
+	assert.True(t, grid.IsOccupied(0, 0), "Tombstones still occupy their cells")
+}
+
+func TestClusterMembersFollowAgents(t *testing.T) {
+	grid, placed := newIndexedGrid("bounded", 20, 20, 9)
+	members := func(cluster int) []infra.IExtendedAgent {
+		kept := slices.DeleteFunc(slices.Clone(placed), func(agent infra.IExtendedAgent) bool { return agent.GetClusterID() != cluster })
+		slices.SortFunc(kept, func(a, b infra.IExtendedAgent) int {
+			aID, bID := a.GetID(), b.GetID()
+			return bytes.Compare(aID[:], bID[:])
+		})
+		return kept
+	}
+	assert.Equal(t, members(1), grid.ClusterMembers(1))
+
+	placed[0].SetClusterID(1)
+	assert.Equal(t, members(1), grid.ClusterMembers(1), "Changing cluster shows up straight away")
+	assert.Equal(t, members(0), grid.ClusterMembers(0))
+
+	grid.RemoveAgent(placed[1])
+	assert.NotContains(t, grid.ClusterMembers(1), placed[1], "Removed agents are no longer members")
+}
+
+func TestProximityRadius(t *testing.T) {
+	for _, radius := range []float64{0, 3} {
+		conf := config.DefaultConfig()
+		conf.GridWidth, conf.GridHeight = 10, 10
+		conf.ProximityRadius = radius
+		serv := server.CreateTMTServer(conf)
+		grid := serv.GetGrid()
+		agent := agents.CreateSecureAgent(serv)
+		near, far := agents.CreateSecureAgent(serv), agents.CreateSecureAgent(serv)
+		for placed, pos := range map[infra.IExtendedAgent]infra.PositionVector{agent: {X: 0, Y: 0}, near: {X: 0, Y: 1}, far: {X: 8, Y: 8}} {
+			placed.SetPosition(pos)
+			placed.SetClusterID(1)
+			grid.PlaceAgent(placed, pos)
+			serv.AddAgent(placed)
+		}
+		grid.PlaceTombstone(1, 0, 0)
+		grid.PlaceTombstone(9, 9, 0)
+
+		proximity := agent.GetMemorialProximity(grid)
+		if radius == 0 {
+			farMate := 1 / grid.Dist(infra.PositionVector{}, infra.PositionVector{X: 8, Y: 8})
+			farTombstone := 1 / grid.Dist(infra.PositionVector{}, infra.PositionVector{X: 9, Y: 9})
+			assert.InDelta(t, (1+farMate)/(2+farMate+farTombstone), proximity, 1e-6, "Everything on the grid counts")
+		} else {
+			assert.InDelta(t, 0.5, proximity, 1e-6, "Only the neighbour and tombstone within the radius count")
+		}
+	}
+}
+
+// run with -race: occupancy queries rebuild the memorial caches that placing a
+// tombstone throws away
+func TestGridQueriesWhileMemorialsChange(t *testing.T) {
+	grid, placed := newIndexedGrid("toroidal", 30, 30, 20)
+	var wg sync.WaitGroup
+	for worker := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 500 {
+				pos := infra.PositionVector{X: (i + worker) % 30, Y: i / 30 % 30}
+				grid.IsOccupied(pos.X, pos.Y)
+				grid.CanEnter(pos)
+				grid.MemorialsWithin(pos, 3)
+			}
+		}()
+	}
+	for i := range 200 {
+		grid.PlaceTombstone(i%30, 29, i)
+		grid.RandomStep(placed[i%len(placed)].GetPosition())
+	}
+	wg.Wait()
+	assert.True(t, grid.IsOccupied(5, 29))
+}
+
+func BenchmarkNearestAgent(b *testing.B) {
+	grid, placed := newIndexedGrid("bounded", 150, 150, 1500)
+	keep := func(agent infra.IExtendedAgent) bool { return agent.GetClusterID() == 0 }
+	b.Run("index", func(b *testing.B) {
+		for i := range b.N {
+			grid.NearestAgent(placed[i%len(placed)].GetPosition(), keep)
+		}
+	})
+	b.Run("scan", func(b *testing.B) {
+		for i := range b.N {
+			scanNearest(grid, placed[i%len(placed)].GetPosition(), 1, placed, keep)
+		}
+	})
+}
+
+func BenchmarkIsOccupied(b *testing.B) {
+	grid, _ := newIndexedGrid("bounded", 150, 150, 1500)
+	memorials := grid.Memorials()
+	b.Run("index", func(b *testing.B) {
+		for i := range b.N {
+			grid.IsOccupied(i%150, i/150%150)
+		}
+	})
+	b.Run("scan", func(b *testing.B) {
+		for i := range b.N {
+			pos := infra.PositionVector{X: i % 150, Y: i / 150 % 150}
+			_ = slices.ContainsFunc(memorials, func(memorial infra.Memorial) bool { return memorial.PositionVector == pos })
+		}
+	})
+}
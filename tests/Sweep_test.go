@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/aaashah/TMT_FYP/config"
+	"github.com/aaashah/TMT_FYP/sweep"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSweepParameter(t *testing.T) {
+	param, err := sweep.ParseParameter("rho=0:1:0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, "rho", param.Name)
+	assert.Equal(t, []string{"0", "0.1", "0.2", "0.3", "0.4", "0.5", "0.6", "0.7", "0.8", "0.9", "1"}, param.Values)
+
+	param, err = sweep.ParseParameter("kappa=2,3,5")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2", "3", "5"}, param.Values)
+
+	_, err = sweep.ParseParameter("rho")
+	assert.Error(t, err)
+}
+
+func TestSweepExpandsGridAndSeeds(t *testing.T) {
+	s := sweep.Sweep{
+		Base: config.DefaultConfig(),
+		Parameters: []sweep.Parameter{
+			{Name: "rho", Values: []string{"0.1", "0.2"}},
+			{Name: "tau", Values: []string{"0.3", "0.4", "0.5"}},
+		},
+		Replicates: 2,
+	}
+
+	runs, err := s.Runs()
+	assert.NoError(t, err)
+	assert.Equal(t, 2*3*2, len(runs))
+
+	last := runs[len(runs)-1]
+	assert.Equal(t, 0.2, last.Config.PopulationRho)
+	assert.Equal(t, 0.5, last.Config.ASMThreshold)
+	assert.Equal(t, s.Base.Seed+1, last.Config.Seed)
+
+	s.Parameters = append(s.Parameters, sweep.Parameter{Name: "notAParameter", Values: []string{"1"}})
+	_, err = s.Runs()
+	assert.Error(t, err)
+}
+
+func TestSweepResultsIndependentOfWorkers(t *testing.T) {
+	base := config.DefaultConfig()
+	base.NumAgents = 12
+	base.NumIterations = 8
+	base.NumTurns = 3
+	base.GridWidth = 15
+	base.GridHeight = 15
+
+	s := sweep.Sweep{
+		Base:       base,
+		Parameters: []sweep.Parameter{{Name: "rho", Values: []string{"0.1", "0.4"}}},
+		Replicates: 2,
+		Workers:    1,
+	}
+	serial, err := s.Execute(nil)
+	assert.NoError(t, err)
+
+	s.Workers = 4
+	parallel, err := s.Execute(nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, serial, parallel, "Concurrent runs should match serial runs exactly")
+}
@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/aaashah/TMT_FYP/infra"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTopologyGrid(t *testing.T, name string) *infra.Grid {
+	grid := infra.NewGrid(10, 10, rand.New(rand.NewPCG(1, 1)))
+	topology, err := infra.NewTopology(name, 10, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grid.Topology = topology
+	return grid
+}
+
+func TestBoundedTopology(t *testing.T) {
+	grid := newTopologyGrid(t, "bounded")
+	corner := infra.PositionVector{X: 0, Y: 0}
+	far := infra.PositionVector{X: 9, Y: 0}
+
+	_, ok := grid.CanEnter(infra.PositionVector{X: -1, Y: 0})
+	assert.False(t, ok, "Cells off the edge do not exist")
+	_, ok = grid.CanEnter(infra.PositionVector{X: 10, Y: 9})
+	assert.False(t, ok)
+	_, ok = grid.CanEnter(corner)
+	assert.True(t, ok, "Cells start at 0")
+	assert.Equal(t, 9.0, grid.Dist(corner, far))
+	step, ok := grid.StepToward(corner, far)
+	assert.True(t, ok)
+	assert.Equal(t, infra.PositionVector{X: 1, Y: 0}, step)
+
+	// an agent in the corner can only ever move to the two cells next to it
+	for range 20 {
+		step, ok := grid.RandomStep(corner)
+		assert.True(t, ok)
+		assert.Contains(t, []infra.PositionVector{{X: 1, Y: 0}, {X: 0, Y: 1}}, step)
+	}
+}
+
+func TestToroidalTopology(t *testing.T) {
+	grid := newTopologyGrid(t, "toroidal")
+	corner := infra.PositionVector{X: 0, Y: 0}
+	far := infra.PositionVector{X: 9, Y: 0}
+
+	wrapped, ok := grid.CanEnter(infra.PositionVector{X: -1, Y: 10})
+	assert.True(t, ok)
+	assert.Equal(t, infra.PositionVector{X: 9, Y: 0}, wrapped, "Moves off one edge come back on the other")
+	assert.Equal(t, 1.0, grid.Dist(corner, far), "Distances go round the edges")
+	step, ok := grid.StepToward(corner, far)
+	assert.True(t, ok)
+	assert.Equal(t, far, step, "Steps towards a target go round the edges")
+	assert.Equal(t, infra.PositionVector{X: -1, Y: 0}, grid.Displacement(corner, far))
+
+	mean := grid.Topology.Mean([]infra.PositionVector{{X: 9, Y: 5}, {X: 1, Y: 5}})
+	assert.InDelta(t, 0.0, grid.CentroidDist(corner, &infra.Centroid{X: mean.X, Y: 0}), 1e-9, "A cluster straddling the edge is centred on it")
+	assert.InDelta(t, 5.0, mean.Y, 1e-9)
+
+	for range 20 {
+		step, ok := grid.RandomStep(corner)
+		assert.True(t, ok)
+		assert.True(t, step.X >= 0 && step.X < 10 && step.Y >= 0 && step.Y < 10)
+	}
+}